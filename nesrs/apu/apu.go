@@ -0,0 +1,313 @@
+package apu
+
+// Frame sequencer step timing (in CPU cycles). NTSC.
+const (
+	frameStep1          = 7457
+	frameStep2          = 14913
+	frameStep3          = 22371
+	frameStep4          = 29829
+	frameStep5          = 37281
+	frame4StepIRQStart  = frameStep4 - 1
+	frame4StepLength    = frameStep4 + 1
+	frame5StepLength    = frameStep5 + 1
+	frameCounterMode    = 0x80 // $4017 bit 7 (5-step sequence)
+	frameCounterInhibit = 0x40 // $4017 bit 6 (IRQ inhibit)
+)
+
+// $4015 status/enable bits.
+const (
+	statusPulse1   = 0x01
+	statusPulse2   = 0x02
+	statusTriangle = 0x04
+	statusNoise    = 0x08
+	statusDMC      = 0x10
+	statusFrameIRQ = 0x40
+	statusDMCIRQ   = 0x80
+)
+
+// AudioReceiver - handles APU audio output. Samples are 16 bit mono PCM at SampleRate().
+// Batches are reused after ReceiveSamples returns.
+type AudioReceiver interface {
+	SampleRate() int
+	ReceiveSamples(samples []int16)
+}
+
+// AudioBuffer - AudioReceiver which reports samples queued for playback. Used to pace
+// emulation by the audio device clock.
+type AudioBuffer interface {
+	BufferedSamples() int
+}
+
+// IRQReceiver - handles APU IRQ line changes.
+type IRQReceiver interface {
+	ReceiveIRQ(asserted bool)
+}
+
+// APU - NES Audio Processing Unit.
+type APU struct {
+	pulse1            *pulse      // Channels
+	pulse2            *pulse      //
+	triangle          *triangle   //
+	noise             *noise      //
+	dmc               *dmc        //
+	frameCounterReg   int         // Frame sequencer ($4017)
+	frameCycle        int         //
+	frameResetDelay   int         // CPU cycles until $4017 write takes effect
+	isFrameIRQFlagSet bool        //
+	cycle             int         // CPU cycles counter
+	resampler         *resampler  // Audio output
+	isMuted           bool        //
+	irqReceiver       IRQReceiver // Receivers
+}
+
+// New instance of APU. DMC fetches samples from memory and reports stolen CPU cycles to dmaReceiver.
+// Audio output is skipped when audioReceiver is nil.
+func New(memory MemoryReader, irqReceiver IRQReceiver, dmaReceiver DMAReceiver, audioReceiver AudioReceiver) *APU {
+	apu := APU{
+		pulse1:      &pulse{isPulse1: true},
+		pulse2:      &pulse{},
+		triangle:    &triangle{},
+		noise:       &noise{},
+		dmc:         &dmc{memory: memory, dmaReceiver: dmaReceiver},
+		irqReceiver: irqReceiver}
+
+	if audioReceiver != nil {
+		apu.resampler = newResampler(audioReceiver)
+	}
+
+	return &apu
+}
+
+// Init APU (power up).
+func (apu *APU) Init() {
+	for address := 0x4000; address <= 0x4013; address++ {
+		apu.WriteRegister(address, 0x00)
+	}
+	apu.WriteRegister(0x4015, 0x00)
+	apu.noise.shiftReg = 1
+	apu.dmc.timer = 0
+	apu.dmc.bitsRemaining = 8
+	apu.dmc.isBufferEmpty = true
+	apu.dmc.silence = true
+	apu.cycle = 0
+
+	apu.frameCycle = 0
+	apu.frameCounterReg = 0x00
+	apu.frameResetDelay = 0
+	apu.setFrameIRQFlag(false)
+}
+
+// Reset APU. Channels are silenced and the frame sequencer restarts in the last written mode.
+func (apu *APU) Reset() {
+	apu.WriteRegister(0x4015, 0x00)
+	apu.triangle.sequencePosition = 0
+	apu.dmc.outputLevel &= 0x01
+
+	apu.frameCycle = 0
+	apu.frameResetDelay = 0
+	apu.setFrameIRQFlag(false)
+}
+
+// SetMuted stops (or resumes) sending samples to the audio receiver. Channels keep running.
+func (apu *APU) SetMuted(muted bool) {
+	apu.isMuted = muted
+}
+
+// Flush sends samples buffered for the audio receiver right away instead of waiting for a full
+// buffer (1/60 s), e.g. at the end of a frame or before closing a recording.
+func (apu *APU) Flush() {
+	if apu.resampler != nil {
+		apu.resampler.flush()
+	}
+}
+
+// ExecuteCycles runs APU for the given number of CPU cycles.
+func (apu *APU) ExecuteCycles(cpuCycles int) {
+	isDMCIRQFlagSet := apu.dmc.isIRQFlagSet
+
+	for i := 0; i < cpuCycles; i++ {
+		apu.clockFrameCounter()
+
+		apu.triangle.clockTimer()
+		apu.noise.clockTimer()
+		apu.dmc.clockTimer()
+		if (apu.cycle & 0x01) != 0 {
+			// APU cycle
+			apu.pulse1.clockTimer()
+			apu.pulse2.clockTimer()
+		}
+
+		if apu.resampler != nil && !apu.isMuted {
+			apu.resampler.addSample(apu.mix())
+		}
+
+		apu.cycle++
+	}
+
+	if apu.dmc.isIRQFlagSet != isDMCIRQFlagSet {
+		apu.updateIRQ()
+	}
+}
+
+// ReadRegister of APU ($4015).
+func (apu *APU) ReadRegister(address int) int {
+	if address != 0x4015 {
+		return 0
+	}
+
+	status := 0
+	if apu.pulse1.length.isActive() {
+		status |= statusPulse1
+	}
+	if apu.pulse2.length.isActive() {
+		status |= statusPulse2
+	}
+	if apu.triangle.length.isActive() {
+		status |= statusTriangle
+	}
+	if apu.noise.length.isActive() {
+		status |= statusNoise
+	}
+	if apu.dmc.isActive() {
+		status |= statusDMC
+	}
+	if apu.isFrameIRQFlagSet {
+		status |= statusFrameIRQ
+	}
+	if apu.dmc.isIRQFlagSet {
+		status |= statusDMCIRQ
+	}
+
+	// Reading clears the frame IRQ flag
+	apu.setFrameIRQFlag(false)
+
+	return status
+}
+
+// WriteRegister of APU ($4000-$4013, $4015, $4017).
+func (apu *APU) WriteRegister(address int, value int) {
+	switch {
+	case 0x4000 <= address && address <= 0x4003:
+		apu.pulse1.writeRegister(address&0x03, value)
+
+	case 0x4004 <= address && address <= 0x4007:
+		apu.pulse2.writeRegister(address&0x03, value)
+
+	case 0x4008 <= address && address <= 0x400B:
+		apu.triangle.writeRegister(address&0x03, value)
+
+	case 0x400C <= address && address <= 0x400F:
+		apu.noise.writeRegister(address&0x03, value)
+
+	case 0x4010 <= address && address <= 0x4013:
+		apu.dmc.writeRegister(address&0x03, value)
+		apu.updateIRQ()
+
+	case address == 0x4015:
+		apu.pulse1.length.setEnabled((value & statusPulse1) != 0)
+		apu.pulse2.length.setEnabled((value & statusPulse2) != 0)
+		apu.triangle.length.setEnabled((value & statusTriangle) != 0)
+		apu.noise.length.setEnabled((value & statusNoise) != 0)
+		apu.dmc.setEnabled((value & statusDMC) != 0)
+		apu.updateIRQ()
+
+	case address == 0x4017:
+		apu.frameCounterReg = value
+		if (value & frameCounterInhibit) != 0 {
+			apu.setFrameIRQFlag(false)
+		}
+
+		// Sequencer is reset 3 CPU cycles after the write during an APU cycle, 4 otherwise.
+		if (apu.cycle & 0x01) != 0 {
+			apu.frameResetDelay = 3
+		} else {
+			apu.frameResetDelay = 4
+		}
+	}
+}
+
+func (apu *APU) clockFrameCounter() {
+	if apu.frameResetDelay > 0 {
+		apu.frameResetDelay--
+		if apu.frameResetDelay == 0 {
+			apu.frameCycle = 0
+			if (apu.frameCounterReg & frameCounterMode) != 0 {
+				// 5-step mode clocks all units immediately
+				apu.clockQuarterFrame()
+				apu.clockHalfFrame()
+			}
+			return
+		}
+	}
+
+	apu.frameCycle++
+
+	is5StepMode := (apu.frameCounterReg & frameCounterMode) != 0
+
+	switch apu.frameCycle {
+	case frameStep1, frameStep3:
+		apu.clockQuarterFrame()
+
+	case frameStep2:
+		apu.clockQuarterFrame()
+		apu.clockHalfFrame()
+
+	case frame4StepIRQStart:
+		if !is5StepMode {
+			apu.raiseFrameIRQ()
+		}
+
+	case frameStep4:
+		if !is5StepMode {
+			apu.clockQuarterFrame()
+			apu.clockHalfFrame()
+			apu.raiseFrameIRQ()
+		}
+
+	case frame4StepLength:
+		if !is5StepMode {
+			apu.raiseFrameIRQ()
+			apu.frameCycle = 0
+		}
+
+	case frameStep5:
+		apu.clockQuarterFrame()
+		apu.clockHalfFrame()
+
+	case frame5StepLength:
+		apu.frameCycle = 0
+	}
+}
+
+func (apu *APU) clockQuarterFrame() {
+	apu.pulse1.envelope.clock()
+	apu.pulse2.envelope.clock()
+	apu.triangle.clockLinearCounter()
+	apu.noise.envelope.clock()
+}
+
+func (apu *APU) clockHalfFrame() {
+	apu.pulse1.length.clock()
+	apu.pulse1.clockSweep()
+	apu.pulse2.length.clock()
+	apu.pulse2.clockSweep()
+	apu.triangle.length.clock()
+	apu.noise.length.clock()
+}
+
+func (apu *APU) raiseFrameIRQ() {
+	if (apu.frameCounterReg & frameCounterInhibit) == 0 {
+		apu.setFrameIRQFlag(true)
+	}
+}
+
+func (apu *APU) setFrameIRQFlag(isSet bool) {
+	apu.isFrameIRQFlagSet = isSet
+	apu.updateIRQ()
+}
+
+func (apu *APU) updateIRQ() {
+	if apu.irqReceiver != nil {
+		apu.irqReceiver.ReceiveIRQ(apu.isFrameIRQFlagSet || apu.dmc.isIRQFlagSet)
+	}
+}
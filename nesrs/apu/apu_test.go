@@ -0,0 +1,195 @@
+package apu
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"testing"
+)
+
+type testIRQReceiver struct {
+	asserted bool
+}
+
+func (irqReceiver *testIRQReceiver) ReceiveIRQ(asserted bool) {
+	irqReceiver.asserted = asserted
+}
+
+func TestFrameIRQ(t *testing.T) {
+	irqReceiver := &testIRQReceiver{}
+	apu := New(nil, irqReceiver, nil, nil)
+	apu.Init()
+
+	apu.ExecuteCycles(frame4StepIRQStart - 1)
+	if irqReceiver.asserted {
+		t.Errorf("Frame IRQ asserted too early")
+	}
+
+	apu.ExecuteCycles(1)
+	if !irqReceiver.asserted {
+		t.Errorf("Frame IRQ not asserted")
+	}
+
+	if status := apu.ReadRegister(0x4015); (status & statusFrameIRQ) == 0 {
+		t.Errorf("Wrong status %02X", status)
+	}
+	if irqReceiver.asserted || (apu.ReadRegister(0x4015)&statusFrameIRQ) != 0 {
+		t.Errorf("Frame IRQ not acknowledged by $4015 read")
+	}
+
+	// Inhibit
+	apu.WriteRegister(0x4017, frameCounterInhibit)
+	apu.ExecuteCycles(2 * frame4StepLength)
+	if irqReceiver.asserted {
+		t.Errorf("Frame IRQ asserted while inhibited")
+	}
+}
+
+func TestLengthCounter(t *testing.T) {
+	apu := New(nil, nil, nil, nil)
+	apu.Init()
+
+	apu.WriteRegister(0x4015, statusPulse1|statusNoise)
+	apu.WriteRegister(0x4000, 0x10) // constant volume, no halt
+	apu.WriteRegister(0x4003, 0x18) // length index 3 -> 2 half frames
+	apu.WriteRegister(0x4007, 0x08) // pulse 2 disabled, ignored
+	apu.WriteRegister(0x400F, 0x08) // length index 1 -> 254 half frames
+
+	if status := apu.ReadRegister(0x4015); status != statusPulse1|statusNoise {
+		t.Errorf("Wrong status %02X", status)
+	}
+
+	// One frame contains two half frame clocks
+	apu.WriteRegister(0x4017, frameCounterInhibit)
+	apu.ExecuteCycles(frame4StepLength + 4)
+	if status := apu.ReadRegister(0x4015); status != statusNoise {
+		t.Errorf("Wrong status %02X", status)
+	}
+
+	apu.WriteRegister(0x4015, 0x00)
+	if status := apu.ReadRegister(0x4015); status != 0 {
+		t.Errorf("Wrong status %02X", status)
+	}
+}
+
+type testMemory struct {
+	reads []int
+}
+
+func (memory *testMemory) Read(address int) int {
+	memory.reads = append(memory.reads, address)
+	return 0xFF
+}
+
+type testDMAReceiver struct {
+	cpuCycles int
+}
+
+func (dmaReceiver *testDMAReceiver) ReceiveDMA(cpuCycles int) {
+	dmaReceiver.cpuCycles += cpuCycles
+}
+
+func TestDMC(t *testing.T) {
+	memory := &testMemory{}
+	irqReceiver := &testIRQReceiver{}
+	dmaReceiver := &testDMAReceiver{}
+	apu := New(memory, irqReceiver, dmaReceiver, nil)
+	apu.Init()
+	apu.WriteRegister(0x4017, frameCounterInhibit)
+
+	apu.WriteRegister(0x4010, 0x8F) // IRQ, fastest rate
+	apu.WriteRegister(0x4012, 0x01) // $C040
+	apu.WriteRegister(0x4013, 0x01) // 17 bytes
+	apu.WriteRegister(0x4015, statusDMC)
+
+	if len(memory.reads) != 1 || memory.reads[0] != 0xC040 || dmaReceiver.cpuCycles != dmcFetchCycles {
+		t.Errorf("Wrong first fetch %v, %v stolen cycles", memory.reads, dmaReceiver.cpuCycles)
+	}
+
+	apu.ExecuteCycles(17 * 8 * dmcRateTable[0x0F])
+	if len(memory.reads) != 17 || dmaReceiver.cpuCycles != 17*dmcFetchCycles {
+		t.Errorf("Wrong fetches %v, %v stolen cycles", len(memory.reads), dmaReceiver.cpuCycles)
+	}
+	if !irqReceiver.asserted || apu.ReadRegister(0x4015) != statusDMCIRQ {
+		t.Errorf("DMC IRQ not asserted")
+	}
+	if apu.dmc.output() != 0x7F-1 {
+		t.Errorf("Wrong output level %v", apu.dmc.output())
+	}
+
+	apu.WriteRegister(0x4015, 0x00)
+	if irqReceiver.asserted {
+		t.Errorf("DMC IRQ not acknowledged by $4015 write")
+	}
+}
+
+func TestWAVAudioReceiver(t *testing.T) {
+	audioReceiver := &WAVAudioReceiver{Rate: 48000}
+	apu := New(nil, nil, nil, audioReceiver)
+	apu.Init()
+
+	// 440Hz square wave at max volume
+	apu.WriteRegister(0x4015, statusPulse1)
+	apu.WriteRegister(0x4000, 0xBF)
+	apu.WriteRegister(0x4002, 0xFD)
+	apu.WriteRegister(0x4003, 0x00)
+
+	apu.ExecuteCycles(CPUClockRate + CPUClockRate/120)
+
+	// Samples are delivered in batches of 1/60 second, the last half batch on Flush
+	samplesCount := len(audioReceiver.samples)
+	if samplesCount != 48000 {
+		t.Errorf("Wrong samples count before flush %v", samplesCount)
+	}
+	apu.Flush()
+	samplesCount = len(audioReceiver.samples)
+	if samplesCount != 48399 {
+		t.Errorf("Wrong samples count %v", samplesCount)
+	}
+
+	silent := true
+	for _, sample := range audioReceiver.samples {
+		if sample > 1000 || sample < -1000 {
+			silent = false
+			break
+		}
+	}
+	if silent {
+		t.Errorf("No audio output")
+	}
+
+	var b bytes.Buffer
+	if err := audioReceiver.Write(&b); err != nil {
+		t.Fatal(err)
+	}
+	if b.Len() != 44+2*samplesCount || !bytes.HasPrefix(b.Bytes(), []byte("RIFF")) {
+		t.Errorf("Wrong WAV file (%v bytes)", b.Len())
+	}
+}
+
+func TestWAVStreamAudioReceiver(t *testing.T) {
+	file, err := ioutil.TempFile("", "nesrs-*.wav")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(file.Name())
+	defer file.Close()
+
+	audioReceiver := NewWAVStreamAudioReceiver(file, 0)
+	audioReceiver.ReceiveSamples([]int16{1, -1, 2})
+	audioReceiver.ReceiveSamples([]int16{-2})
+	if err := audioReceiver.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := ioutil.ReadFile(file.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var expected bytes.Buffer
+	WriteWAV(&expected, 44100, []int16{1, -1, 2, -2})
+	if audioReceiver.Samples() != 4 || !bytes.Equal(data, expected.Bytes()) {
+		t.Errorf("Wrong WAV file (%v bytes)", len(data))
+	}
+}
@@ -0,0 +1,154 @@
+package apu
+
+// DMC timer periods (in CPU cycles) indexed by $4010 bits 0-3. NTSC.
+var dmcRateTable = [16]int{428, 380, 340, 320, 286, 254, 226, 214, 190, 160, 142, 128, 106, 84, 72, 54}
+
+// CPU cycles stolen by each sample fetch.
+const dmcFetchCycles = 4
+
+// MemoryReader - CPU memory DMC samples are fetched from.
+type MemoryReader interface {
+	Read(address int) int
+}
+
+// DMAReceiver - handles CPU cycles stolen by DMC sample fetches.
+type DMAReceiver interface {
+	ReceiveDMA(cpuCycles int)
+}
+
+// Delta modulation channel. $4010-$4013.
+type dmc struct {
+	memory         MemoryReader
+	dmaReceiver    DMAReceiver
+	irqEnabled     bool
+	isIRQFlagSet   bool
+	loop           bool
+	timerPeriod    int
+	timer          int
+	outputLevel    int // 7 bits
+	sampleAddress  int // $4012: $C000 + A*64
+	sampleLength   int // $4013: L*16 + 1
+	currentAddress int // Memory reader
+	bytesRemaining int //
+	sampleBuffer   int //
+	isBufferEmpty  bool
+	shiftReg       int // Output unit
+	bitsRemaining  int //
+	silence        bool
+}
+
+func (dmc *dmc) writeRegister(register int, value int) {
+	switch register {
+	case 0:
+		// IL-- RRRR
+		dmc.irqEnabled = (value & 0x80) != 0
+		dmc.loop = (value & 0x40) != 0
+		dmc.timerPeriod = dmcRateTable[value&0x0F]
+		if !dmc.irqEnabled {
+			dmc.isIRQFlagSet = false
+		}
+
+	case 1:
+		// -DDD DDDD
+		dmc.outputLevel = value & 0x7F
+
+	case 2:
+		// AAAA AAAA
+		dmc.sampleAddress = 0xC000 | (value << 6)
+
+	case 3:
+		// LLLL LLLL
+		dmc.sampleLength = (value << 4) | 0x0001
+	}
+}
+
+// setEnabled from $4015 write. Also acknowledges DMC IRQ.
+func (dmc *dmc) setEnabled(enabled bool) {
+	dmc.isIRQFlagSet = false
+
+	if !enabled {
+		dmc.bytesRemaining = 0
+	} else if dmc.bytesRemaining == 0 {
+		dmc.restart()
+		dmc.fetchSample()
+	}
+}
+
+func (dmc *dmc) restart() {
+	dmc.currentAddress = dmc.sampleAddress
+	dmc.bytesRemaining = dmc.sampleLength
+}
+
+func (dmc *dmc) isActive() bool {
+	return dmc.bytesRemaining > 0
+}
+
+// fetchSample fills the sample buffer through the CPU memory, stalling the CPU.
+func (dmc *dmc) fetchSample() {
+	if !dmc.isBufferEmpty || dmc.bytesRemaining == 0 {
+		return
+	}
+
+	if dmc.dmaReceiver != nil {
+		dmc.dmaReceiver.ReceiveDMA(dmcFetchCycles)
+	}
+	if dmc.memory != nil {
+		dmc.sampleBuffer = dmc.memory.Read(dmc.currentAddress) & 0xFF
+	}
+	dmc.isBufferEmpty = false
+
+	// Address wraps around to $8000
+	dmc.currentAddress++
+	if dmc.currentAddress > 0xFFFF {
+		dmc.currentAddress = 0x8000
+	}
+
+	dmc.bytesRemaining--
+	if dmc.bytesRemaining == 0 {
+		if dmc.loop {
+			dmc.restart()
+		} else if dmc.irqEnabled {
+			dmc.isIRQFlagSet = true
+		}
+	}
+}
+
+// clockTimer every CPU cycle.
+func (dmc *dmc) clockTimer() {
+	if dmc.timer > 0 {
+		dmc.timer--
+		return
+	}
+	dmc.timer = dmc.timerPeriod - 1
+
+	if !dmc.silence {
+		if (dmc.shiftReg & 0x01) != 0 {
+			if dmc.outputLevel <= 125 {
+				dmc.outputLevel += 2
+			}
+		} else {
+			if dmc.outputLevel >= 2 {
+				dmc.outputLevel -= 2
+			}
+		}
+	}
+	dmc.shiftReg >>= 1
+
+	dmc.bitsRemaining--
+	if dmc.bitsRemaining <= 0 {
+		// New output cycle
+		dmc.bitsRemaining = 8
+		if dmc.isBufferEmpty {
+			dmc.silence = true
+		} else {
+			dmc.silence = false
+			dmc.shiftReg = dmc.sampleBuffer
+			dmc.isBufferEmpty = true
+			dmc.fetchSample()
+		}
+	}
+}
+
+func (dmc *dmc) output() int {
+	return dmc.outputLevel
+}
@@ -0,0 +1,85 @@
+package apu
+
+// Length counter load values indexed by bits 3-7 of $4003/$4007/$400B/$400F.
+var lengthTable = [32]int{
+	/*      0    1   2   3   4   5   6   7   8   9   A   B   C   D   E   F*/
+	/*0x00*/ 10, 254, 20, 2, 40, 4, 80, 6, 160, 8, 60, 10, 14, 12, 26, 14,
+	/*0x10*/ 12, 16, 24, 18, 48, 20, 96, 22, 192, 24, 72, 26, 16, 28, 32, 30}
+
+// Length counter - silences the channel after a given number of half frames.
+type lengthCounter struct {
+	enabled bool // $4015 channel enable
+	halt    bool // Also envelope loop / linear counter control flag
+	counter int
+}
+
+func (length *lengthCounter) setEnabled(enabled bool) {
+	length.enabled = enabled
+	if !enabled {
+		length.counter = 0
+	}
+}
+
+func (length *lengthCounter) load(index int) {
+	if length.enabled {
+		length.counter = lengthTable[index&0x1F]
+	}
+}
+
+// clock on half frame.
+func (length *lengthCounter) clock() {
+	if length.counter > 0 && !length.halt {
+		length.counter--
+	}
+}
+
+func (length *lengthCounter) isActive() bool {
+	return length.counter > 0
+}
+
+// Envelope generator - constant volume or decaying saw envelope.
+type envelope struct {
+	start          bool
+	loop           bool
+	constantVolume bool
+	volume         int // Constant volume or envelope period (4 bits)
+	divider        int
+	decayLevel     int
+}
+
+// write $4000/$4004/$400C (--LC VVVV).
+func (env *envelope) write(value int) {
+	env.loop = (value & 0x20) != 0
+	env.constantVolume = (value & 0x10) != 0
+	env.volume = value & 0x0F
+}
+
+// clock on quarter frame.
+func (env *envelope) clock() {
+	if env.start {
+		env.start = false
+		env.decayLevel = 15
+		env.divider = env.volume
+		return
+	}
+
+	if env.divider > 0 {
+		env.divider--
+		return
+	}
+
+	env.divider = env.volume
+	if env.decayLevel > 0 {
+		env.decayLevel--
+	} else if env.loop {
+		env.decayLevel = 15
+	}
+}
+
+func (env *envelope) output() int {
+	if env.constantVolume {
+		return env.volume
+	}
+
+	return env.decayLevel
+}
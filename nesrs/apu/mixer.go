@@ -0,0 +1,26 @@
+package apu
+
+// Nonlinear mixer lookup tables.
+// pulse = 95.52 / (8128 / (pulse1 + pulse2) + 100)
+// tnd = 163.67 / (24329 / (3 * triangle + 2 * noise + dmc) + 100)
+var (
+	pulseTable [31]float64
+	tndTable   [203]float64
+)
+
+func init() {
+	for i := 1; i < len(pulseTable); i++ {
+		pulseTable[i] = 95.52 / (8128.0/float64(i) + 100)
+	}
+	for i := 1; i < len(tndTable); i++ {
+		tndTable[i] = 163.67 / (24329.0/float64(i) + 100)
+	}
+}
+
+// mix channel outputs. Result is in range [0, 1).
+func (apu *APU) mix() float64 {
+	pulseOutput := pulseTable[apu.pulse1.output()+apu.pulse2.output()]
+	tndOutput := tndTable[3*apu.triangle.output()+2*apu.noise.output()+apu.dmc.output()]
+
+	return pulseOutput + tndOutput
+}
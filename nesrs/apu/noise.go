@@ -0,0 +1,58 @@
+package apu
+
+// Noise timer periods (in CPU cycles) indexed by $400E bits 0-3. NTSC.
+var noisePeriodTable = [16]int{4, 8, 16, 32, 64, 96, 128, 160, 202, 254, 380, 508, 762, 1016, 2034, 4068}
+
+// Noise channel. $400C-$400F.
+type noise struct {
+	envelope    envelope
+	length      lengthCounter
+	mode        bool // Short (93 step) sequence
+	timerPeriod int
+	timer       int
+	shiftReg    int // 15 bit LFSR
+}
+
+func (noise *noise) writeRegister(register int, value int) {
+	switch register {
+	case 0:
+		// --LC VVVV
+		noise.length.halt = (value & 0x20) != 0
+		noise.envelope.write(value)
+
+	case 2:
+		// M--- PPPP
+		noise.mode = (value & 0x80) != 0
+		noise.timerPeriod = noisePeriodTable[value&0x0F]
+
+	case 3:
+		// LLLL L---
+		noise.length.load(value >> 3)
+		noise.envelope.start = true
+	}
+}
+
+// clockTimer every CPU cycle.
+func (noise *noise) clockTimer() {
+	if noise.timer > 0 {
+		noise.timer--
+		return
+	}
+	noise.timer = noise.timerPeriod - 1
+
+	// Feedback is bit 0 XOR bit 6 (short mode) or bit 1.
+	otherBit := 1
+	if noise.mode {
+		otherBit = 6
+	}
+	feedback := (noise.shiftReg & 0x01) ^ ((noise.shiftReg >> uint(otherBit)) & 0x01)
+	noise.shiftReg = (noise.shiftReg >> 1) | (feedback << 14)
+}
+
+func (noise *noise) output() int {
+	if !noise.length.isActive() || (noise.shiftReg&0x01) != 0 {
+		return 0
+	}
+
+	return noise.envelope.output()
+}
@@ -0,0 +1,110 @@
+package apu
+
+var pulseDutyTable = [4][8]int{
+	{0, 1, 0, 0, 0, 0, 0, 0}, // 12.5%
+	{0, 1, 1, 0, 0, 0, 0, 0}, // 25%
+	{0, 1, 1, 1, 1, 0, 0, 0}, // 50%
+	{1, 0, 0, 1, 1, 1, 1, 1}, // 25% negated
+}
+
+// Pulse (square wave) channel. $4000-$4003 and $4004-$4007.
+type pulse struct {
+	isPulse1     bool // Pulse 1 sweep negates with ones' complement
+	envelope     envelope
+	length       lengthCounter
+	duty         int
+	dutyPosition int
+	timerPeriod  int // 11 bits
+	timer        int
+	sweepEnabled bool
+	sweepPeriod  int
+	sweepNegate  bool
+	sweepShift   int
+	sweepDivider int
+	sweepReload  bool
+}
+
+func (pulse *pulse) writeRegister(register int, value int) {
+	switch register {
+	case 0:
+		// DDLC VVVV
+		pulse.duty = (value >> 6) & 0x03
+		pulse.length.halt = (value & 0x20) != 0
+		pulse.envelope.write(value)
+
+	case 1:
+		// EPPP NSSS
+		pulse.sweepEnabled = (value & 0x80) != 0
+		pulse.sweepPeriod = (value >> 4) & 0x07
+		pulse.sweepNegate = (value & 0x08) != 0
+		pulse.sweepShift = value & 0x07
+		pulse.sweepReload = true
+
+	case 2:
+		// TTTT TTTT
+		pulse.timerPeriod = (pulse.timerPeriod & 0x0700) | (value & 0xFF)
+
+	case 3:
+		// LLLL LTTT
+		pulse.timerPeriod = (pulse.timerPeriod & 0x00FF) | ((value & 0x07) << 8)
+		pulse.length.load(value >> 3)
+		pulse.envelope.start = true
+		pulse.dutyPosition = 0
+	}
+}
+
+// clockTimer every APU cycle (2 CPU cycles).
+func (pulse *pulse) clockTimer() {
+	if pulse.timer == 0 {
+		pulse.timer = pulse.timerPeriod
+		pulse.dutyPosition = (pulse.dutyPosition - 1) & 0x07
+	} else {
+		pulse.timer--
+	}
+}
+
+// clockSweep on half frame.
+func (pulse *pulse) clockSweep() {
+	if pulse.sweepDivider == 0 && pulse.sweepEnabled && pulse.sweepShift > 0 && !pulse.isSweepMuting() {
+		pulse.timerPeriod = pulse.sweepTargetPeriod()
+	}
+
+	if pulse.sweepDivider == 0 || pulse.sweepReload {
+		pulse.sweepDivider = pulse.sweepPeriod
+		pulse.sweepReload = false
+	} else {
+		pulse.sweepDivider--
+	}
+}
+
+func (pulse *pulse) sweepTargetPeriod() int {
+	change := pulse.timerPeriod >> uint(pulse.sweepShift)
+	if pulse.sweepNegate {
+		change = -change
+		if pulse.isPulse1 {
+			change--
+		}
+	}
+
+	target := pulse.timerPeriod + change
+	if target < 0 {
+		target = 0
+	}
+
+	return target
+}
+
+// isSweepMuting - sweep mutes the channel even when disabled.
+func (pulse *pulse) isSweepMuting() bool {
+	return pulse.timerPeriod < 8 || pulse.sweepTargetPeriod() > 0x7FF
+}
+
+func (pulse *pulse) output() int {
+	if !pulse.length.isActive() ||
+		pulse.isSweepMuting() ||
+		pulseDutyTable[pulse.duty][pulse.dutyPosition] == 0 {
+		return 0
+	}
+
+	return pulse.envelope.output()
+}
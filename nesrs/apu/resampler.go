@@ -0,0 +1,164 @@
+package apu
+
+import (
+	"math"
+)
+
+// CPUClockRate (NTSC) at which APU produces samples.
+const CPUClockRate = 1789773
+
+const (
+	resamplerOversampling = 4  // Intermediate rate = output rate * oversampling
+	resamplerTaps         = 32 // Low-pass FIR length at intermediate rate
+)
+
+// Resampler converts mixer output at CPU clock rate to PCM samples at the audio receiver's rate.
+// Box filter decimates to an intermediate rate, then windowed-sinc FIR band-limits to the output
+// Nyquist frequency and decimates again. NES filter chain (two high-pass and a low-pass) follows.
+type resampler struct {
+	audioReceiver AudioReceiver
+	inputRate     int
+	outputRate    int
+	phase         int     // Box filter (in input rate * intermediate rate units)
+	sum           float64 //
+	count         int     //
+	firKernel     [resamplerTaps]float64
+	firHistory    [resamplerTaps]float64
+	firPosition   int
+	firPhase      int
+	highPass90    *highPassFilter
+	highPass440   *highPassFilter
+	lowPass14k    *lowPassFilter
+	buffer        []int16
+}
+
+func newResampler(audioReceiver AudioReceiver) *resampler {
+	outputRate := audioReceiver.SampleRate()
+	intermediateRate := outputRate * resamplerOversampling
+
+	resampler := resampler{
+		audioReceiver: audioReceiver,
+		inputRate:     CPUClockRate,
+		outputRate:    outputRate,
+		highPass90:    newHighPassFilter(outputRate, 90),
+		highPass440:   newHighPassFilter(outputRate, 440),
+		lowPass14k:    newLowPassFilter(outputRate, 14000),
+		buffer:        make([]int16, 0, outputRate/60)}
+
+	// Blackman windowed sinc with cutoff slightly below output Nyquist frequency.
+	cutoff := 0.45 * float64(outputRate) / float64(intermediateRate)
+	sum := 0.0
+	for i := range resampler.firKernel {
+		x := float64(i) - float64(resamplerTaps-1)/2
+		sinc := 2 * cutoff
+		if x != 0 {
+			sinc = math.Sin(2*math.Pi*cutoff*x) / (math.Pi * x)
+		}
+		window := 0.42 -
+			0.5*math.Cos(2*math.Pi*float64(i)/float64(resamplerTaps-1)) +
+			0.08*math.Cos(4*math.Pi*float64(i)/float64(resamplerTaps-1))
+		resampler.firKernel[i] = sinc * window
+		sum += resampler.firKernel[i]
+	}
+	for i := range resampler.firKernel {
+		resampler.firKernel[i] /= sum
+	}
+
+	return &resampler
+}
+
+// addSample at CPU clock rate.
+func (resampler *resampler) addSample(sample float64) {
+	resampler.sum += sample
+	resampler.count++
+
+	resampler.phase += resampler.outputRate * resamplerOversampling
+	if resampler.phase >= resampler.inputRate {
+		resampler.phase -= resampler.inputRate
+
+		resampler.addIntermediateSample(resampler.sum / float64(resampler.count))
+		resampler.sum = 0
+		resampler.count = 0
+	}
+}
+
+func (resampler *resampler) addIntermediateSample(sample float64) {
+	resampler.firHistory[resampler.firPosition] = sample
+	resampler.firPosition = (resampler.firPosition + 1) % resamplerTaps
+
+	resampler.firPhase++
+	if resampler.firPhase < resamplerOversampling {
+		return
+	}
+	resampler.firPhase = 0
+
+	output := 0.0
+	for i, coefficient := range resampler.firKernel {
+		output += coefficient * resampler.firHistory[(resampler.firPosition+i)%resamplerTaps]
+	}
+
+	resampler.addOutputSample(output)
+}
+
+func (resampler *resampler) addOutputSample(sample float64) {
+	sample = resampler.highPass90.filter(sample)
+	sample = resampler.highPass440.filter(sample)
+	sample = resampler.lowPass14k.filter(sample)
+
+	pcm := sample * math.MaxInt16
+	if pcm > math.MaxInt16 {
+		pcm = math.MaxInt16
+	} else if pcm < math.MinInt16 {
+		pcm = math.MinInt16
+	}
+	resampler.buffer = append(resampler.buffer, int16(pcm))
+
+	if len(resampler.buffer) == cap(resampler.buffer) {
+		resampler.flush()
+	}
+}
+
+// flush buffered samples to the audio receiver.
+func (resampler *resampler) flush() {
+	if len(resampler.buffer) > 0 {
+		resampler.audioReceiver.ReceiveSamples(resampler.buffer)
+		resampler.buffer = resampler.buffer[:0]
+	}
+}
+
+// First order high-pass filter.
+type highPassFilter struct {
+	alpha          float64
+	previousInput  float64
+	previousOutput float64
+}
+
+func newHighPassFilter(sampleRate int, cutoff float64) *highPassFilter {
+	rc := 1 / (2 * math.Pi * cutoff)
+	dt := 1 / float64(sampleRate)
+	return &highPassFilter{alpha: rc / (rc + dt)}
+}
+
+func (filter *highPassFilter) filter(input float64) float64 {
+	output := filter.alpha * (filter.previousOutput + input - filter.previousInput)
+	filter.previousInput = input
+	filter.previousOutput = output
+	return output
+}
+
+// First order low-pass filter.
+type lowPassFilter struct {
+	alpha          float64
+	previousOutput float64
+}
+
+func newLowPassFilter(sampleRate int, cutoff float64) *lowPassFilter {
+	rc := 1 / (2 * math.Pi * cutoff)
+	dt := 1 / float64(sampleRate)
+	return &lowPassFilter{alpha: dt / (rc + dt)}
+}
+
+func (filter *lowPassFilter) filter(input float64) float64 {
+	filter.previousOutput += filter.alpha * (input - filter.previousOutput)
+	return filter.previousOutput
+}
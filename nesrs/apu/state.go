@@ -0,0 +1,169 @@
+package apu
+
+import (
+	"github.com/alpetkov/nesrs_go/nesrs/state"
+)
+
+// SaveState of the APU. Audio output (resampler) isn't part of the state.
+func (apu *APU) SaveState(writer *state.Writer) {
+	apu.pulse1.saveState(writer)
+	apu.pulse2.saveState(writer)
+	apu.triangle.saveState(writer)
+	apu.noise.saveState(writer)
+	apu.dmc.saveState(writer)
+
+	writer.WriteInt(apu.frameCounterReg)
+	writer.WriteInt(apu.frameCycle)
+	writer.WriteInt(apu.frameResetDelay)
+	writer.WriteBool(apu.isFrameIRQFlagSet)
+	writer.WriteInt(apu.cycle)
+}
+
+// LoadState of the APU.
+func (apu *APU) LoadState(reader *state.Reader) {
+	apu.pulse1.loadState(reader)
+	apu.pulse2.loadState(reader)
+	apu.triangle.loadState(reader)
+	apu.noise.loadState(reader)
+	apu.dmc.loadState(reader)
+
+	apu.frameCounterReg = reader.ReadInt()
+	apu.frameCycle = reader.ReadInt()
+	apu.frameResetDelay = reader.ReadInt()
+	apu.isFrameIRQFlagSet = reader.ReadBool()
+	apu.cycle = reader.ReadInt()
+}
+
+func (length *lengthCounter) saveState(writer *state.Writer) {
+	writer.WriteBool(length.enabled)
+	writer.WriteBool(length.halt)
+	writer.WriteInt(length.counter)
+}
+
+func (length *lengthCounter) loadState(reader *state.Reader) {
+	length.enabled = reader.ReadBool()
+	length.halt = reader.ReadBool()
+	length.counter = reader.ReadInt()
+}
+
+func (env *envelope) saveState(writer *state.Writer) {
+	writer.WriteBool(env.start)
+	writer.WriteBool(env.loop)
+	writer.WriteBool(env.constantVolume)
+	writer.WriteInt(env.volume)
+	writer.WriteInt(env.divider)
+	writer.WriteInt(env.decayLevel)
+}
+
+func (env *envelope) loadState(reader *state.Reader) {
+	env.start = reader.ReadBool()
+	env.loop = reader.ReadBool()
+	env.constantVolume = reader.ReadBool()
+	env.volume = reader.ReadIntRange(0, 0x10)
+	env.divider = reader.ReadInt()
+	env.decayLevel = reader.ReadIntRange(0, 0x10)
+}
+
+func (pulse *pulse) saveState(writer *state.Writer) {
+	pulse.envelope.saveState(writer)
+	pulse.length.saveState(writer)
+	writer.WriteInt(pulse.duty)
+	writer.WriteInt(pulse.dutyPosition)
+	writer.WriteInt(pulse.timerPeriod)
+	writer.WriteInt(pulse.timer)
+	writer.WriteBool(pulse.sweepEnabled)
+	writer.WriteInt(pulse.sweepPeriod)
+	writer.WriteBool(pulse.sweepNegate)
+	writer.WriteInt(pulse.sweepShift)
+	writer.WriteInt(pulse.sweepDivider)
+	writer.WriteBool(pulse.sweepReload)
+}
+
+func (pulse *pulse) loadState(reader *state.Reader) {
+	pulse.envelope.loadState(reader)
+	pulse.length.loadState(reader)
+	pulse.duty = reader.ReadIntRange(0, len(pulseDutyTable))
+	pulse.dutyPosition = reader.ReadIntRange(0, len(pulseDutyTable[0]))
+	pulse.timerPeriod = reader.ReadInt()
+	pulse.timer = reader.ReadInt()
+	pulse.sweepEnabled = reader.ReadBool()
+	pulse.sweepPeriod = reader.ReadInt()
+	pulse.sweepNegate = reader.ReadBool()
+	pulse.sweepShift = reader.ReadInt()
+	pulse.sweepDivider = reader.ReadInt()
+	pulse.sweepReload = reader.ReadBool()
+}
+
+func (triangle *triangle) saveState(writer *state.Writer) {
+	triangle.length.saveState(writer)
+	writer.WriteInt(triangle.linearCounter)
+	writer.WriteInt(triangle.linearCounterPeriod)
+	writer.WriteBool(triangle.linearCounterReload)
+	writer.WriteInt(triangle.timerPeriod)
+	writer.WriteInt(triangle.timer)
+	writer.WriteInt(triangle.sequencePosition)
+}
+
+func (triangle *triangle) loadState(reader *state.Reader) {
+	triangle.length.loadState(reader)
+	triangle.linearCounter = reader.ReadInt()
+	triangle.linearCounterPeriod = reader.ReadInt()
+	triangle.linearCounterReload = reader.ReadBool()
+	triangle.timerPeriod = reader.ReadInt()
+	triangle.timer = reader.ReadInt()
+	triangle.sequencePosition = reader.ReadIntRange(0, len(triangleSequence))
+}
+
+func (noise *noise) saveState(writer *state.Writer) {
+	noise.envelope.saveState(writer)
+	noise.length.saveState(writer)
+	writer.WriteBool(noise.mode)
+	writer.WriteInt(noise.timerPeriod)
+	writer.WriteInt(noise.timer)
+	writer.WriteInt(noise.shiftReg)
+}
+
+func (noise *noise) loadState(reader *state.Reader) {
+	noise.envelope.loadState(reader)
+	noise.length.loadState(reader)
+	noise.mode = reader.ReadBool()
+	noise.timerPeriod = reader.ReadInt()
+	noise.timer = reader.ReadInt()
+	noise.shiftReg = reader.ReadInt()
+}
+
+func (dmc *dmc) saveState(writer *state.Writer) {
+	writer.WriteBool(dmc.irqEnabled)
+	writer.WriteBool(dmc.isIRQFlagSet)
+	writer.WriteBool(dmc.loop)
+	writer.WriteInt(dmc.timerPeriod)
+	writer.WriteInt(dmc.timer)
+	writer.WriteInt(dmc.outputLevel)
+	writer.WriteInt(dmc.sampleAddress)
+	writer.WriteInt(dmc.sampleLength)
+	writer.WriteInt(dmc.currentAddress)
+	writer.WriteInt(dmc.bytesRemaining)
+	writer.WriteInt(dmc.sampleBuffer)
+	writer.WriteBool(dmc.isBufferEmpty)
+	writer.WriteInt(dmc.shiftReg)
+	writer.WriteInt(dmc.bitsRemaining)
+	writer.WriteBool(dmc.silence)
+}
+
+func (dmc *dmc) loadState(reader *state.Reader) {
+	dmc.irqEnabled = reader.ReadBool()
+	dmc.isIRQFlagSet = reader.ReadBool()
+	dmc.loop = reader.ReadBool()
+	dmc.timerPeriod = reader.ReadInt()
+	dmc.timer = reader.ReadInt()
+	dmc.outputLevel = reader.ReadIntRange(0, 0x80)
+	dmc.sampleAddress = reader.ReadIntRange(0, 0x10000)
+	dmc.sampleLength = reader.ReadInt()
+	dmc.currentAddress = reader.ReadIntRange(0, 0x10000)
+	dmc.bytesRemaining = reader.ReadInt()
+	dmc.sampleBuffer = reader.ReadInt()
+	dmc.isBufferEmpty = reader.ReadBool()
+	dmc.shiftReg = reader.ReadInt()
+	dmc.bitsRemaining = reader.ReadInt()
+	dmc.silence = reader.ReadBool()
+}
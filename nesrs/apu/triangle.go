@@ -0,0 +1,66 @@
+package apu
+
+var triangleSequence = [32]int{
+	15, 14, 13, 12, 11, 10, 9, 8, 7, 6, 5, 4, 3, 2, 1, 0,
+	0, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15}
+
+// Triangle channel. $4008-$400B.
+type triangle struct {
+	length              lengthCounter
+	linearCounter       int
+	linearCounterPeriod int
+	linearCounterReload bool
+	timerPeriod         int // 11 bits
+	timer               int
+	sequencePosition    int
+}
+
+func (triangle *triangle) writeRegister(register int, value int) {
+	switch register {
+	case 0:
+		// CRRR RRRR
+		triangle.length.halt = (value & 0x80) != 0
+		triangle.linearCounterPeriod = value & 0x7F
+
+	case 2:
+		// TTTT TTTT
+		triangle.timerPeriod = (triangle.timerPeriod & 0x0700) | (value & 0xFF)
+
+	case 3:
+		// LLLL LTTT
+		triangle.timerPeriod = (triangle.timerPeriod & 0x00FF) | ((value & 0x07) << 8)
+		triangle.length.load(value >> 3)
+		triangle.linearCounterReload = true
+	}
+}
+
+// clockTimer every CPU cycle.
+func (triangle *triangle) clockTimer() {
+	if triangle.timer == 0 {
+		triangle.timer = triangle.timerPeriod
+		if triangle.length.isActive() && triangle.linearCounter > 0 {
+			triangle.sequencePosition = (triangle.sequencePosition + 1) & 0x1F
+		}
+	} else {
+		triangle.timer--
+	}
+}
+
+// clockLinearCounter on quarter frame.
+func (triangle *triangle) clockLinearCounter() {
+	if triangle.linearCounterReload {
+		triangle.linearCounter = triangle.linearCounterPeriod
+	} else if triangle.linearCounter > 0 {
+		triangle.linearCounter--
+	}
+
+	// Control flag (same bit as length counter halt)
+	if !triangle.length.halt {
+		triangle.linearCounterReload = false
+	}
+}
+
+func (triangle *triangle) output() int {
+	// Sequencer is simply stopped when silenced, so output holds its last value.
+	return triangleSequence[triangle.sequencePosition]
+}
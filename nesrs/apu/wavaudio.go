@@ -0,0 +1,69 @@
+package apu
+
+import (
+	"encoding/binary"
+	"io"
+)
+
+// Default output rate of WAVAudioReceiver.
+const defaultSampleRate = 44100
+
+// WAVAudioReceiver records APU output in memory and writes it as 16 bit mono WAV.
+type WAVAudioReceiver struct {
+	Rate    int // Sample rate (44100 if not set)
+	samples []int16
+}
+
+// SampleRate .
+func (wavAudioReceiver *WAVAudioReceiver) SampleRate() int {
+	if wavAudioReceiver.Rate == 0 {
+		return defaultSampleRate
+	}
+
+	return wavAudioReceiver.Rate
+}
+
+// ReceiveSamples .
+func (wavAudioReceiver *WAVAudioReceiver) ReceiveSamples(samples []int16) {
+	wavAudioReceiver.samples = append(wavAudioReceiver.samples, samples...)
+}
+
+// Write . Samples still buffered by the APU aren't included, see APU.Flush (NES flushes them at
+// the end of every frame and on Stop).
+func (wavAudioReceiver *WAVAudioReceiver) Write(out io.Writer) error {
+	return WriteWAV(out, wavAudioReceiver.SampleRate(), wavAudioReceiver.samples)
+}
+
+// WriteWAV writes 16 bit mono PCM samples as RIFF WAVE.
+func WriteWAV(out io.Writer, sampleRate int, samples []int16) error {
+	if err := writeWAVHeader(out, sampleRate, uint32(len(samples)*2)); err != nil {
+		return err
+	}
+
+	return binary.Write(out, binary.LittleEndian, samples)
+}
+
+func writeWAVHeader(out io.Writer, sampleRate int, dataSize uint32) error {
+	header := []interface{}{
+		[4]byte{'R', 'I', 'F', 'F'},
+		36 + dataSize,
+		[4]byte{'W', 'A', 'V', 'E'},
+		[4]byte{'f', 'm', 't', ' '},
+		uint32(16),             // fmt chunk size
+		uint16(1),              // PCM
+		uint16(1),              // Mono
+		uint32(sampleRate),     // Sample rate
+		uint32(sampleRate * 2), // Byte rate
+		uint16(2),              // Block align
+		uint16(16),             // Bits per sample
+		[4]byte{'d', 'a', 't', 'a'},
+		dataSize,
+	}
+	for _, field := range header {
+		if err := binary.Write(out, binary.LittleEndian, field); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,77 @@
+package apu
+
+import (
+	"encoding/binary"
+	"io"
+)
+
+// WAVStreamAudioReceiver writes APU output as 16 bit mono WAV while running, for captures too
+// long to keep in memory. The header sizes are fixed up by Close.
+type WAVStreamAudioReceiver struct {
+	out      io.WriteSeeker
+	rate     int
+	samples  int
+	isHeader bool
+	err      error
+}
+
+// NewWAVStreamAudioReceiver writing to out (e.g. *os.File) at the sample rate (44100 if 0).
+func NewWAVStreamAudioReceiver(out io.WriteSeeker, rate int) *WAVStreamAudioReceiver {
+	if rate == 0 {
+		rate = defaultSampleRate
+	}
+
+	wavStreamAudioReceiver := WAVStreamAudioReceiver{
+		out:  out,
+		rate: rate}
+
+	return &wavStreamAudioReceiver
+}
+
+// SampleRate .
+func (wavStreamAudioReceiver *WAVStreamAudioReceiver) SampleRate() int {
+	return wavStreamAudioReceiver.rate
+}
+
+// ReceiveSamples .
+func (wavStreamAudioReceiver *WAVStreamAudioReceiver) ReceiveSamples(samples []int16) {
+	if wavStreamAudioReceiver.err != nil {
+		return
+	}
+
+	if !wavStreamAudioReceiver.isHeader {
+		wavStreamAudioReceiver.isHeader = true
+		wavStreamAudioReceiver.err = writeWAVHeader(wavStreamAudioReceiver.out, wavStreamAudioReceiver.rate, 0)
+		if wavStreamAudioReceiver.err != nil {
+			return
+		}
+	}
+
+	wavStreamAudioReceiver.err = binary.Write(wavStreamAudioReceiver.out, binary.LittleEndian, samples)
+	wavStreamAudioReceiver.samples += len(samples)
+}
+
+// Samples - number of samples written.
+func (wavStreamAudioReceiver *WAVStreamAudioReceiver) Samples() int {
+	return wavStreamAudioReceiver.samples
+}
+
+// Close writes the final header. It doesn't close the underlying writer. Returns the first
+// error of the whole stream. Flush the APU (NES flushes at the end of every frame and on Stop)
+// before closing to include all samples.
+func (wavStreamAudioReceiver *WAVStreamAudioReceiver) Close() error {
+	if wavStreamAudioReceiver.err != nil {
+		return wavStreamAudioReceiver.err
+	}
+
+	if _, err := wavStreamAudioReceiver.out.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+	dataSize := uint32(wavStreamAudioReceiver.samples * 2)
+	if err := writeWAVHeader(wavStreamAudioReceiver.out, wavStreamAudioReceiver.rate, dataSize); err != nil {
+		return err
+	}
+	_, err := wavStreamAudioReceiver.out.Seek(0, io.SeekEnd)
+
+	return err
+}
@@ -0,0 +1,35 @@
+package cartridge
+
+// AxROM (mapper 7). Switchable 32KB PRG ROM bank and single screen mirroring select.
+type axrom struct {
+	baseMapper
+}
+
+const (
+	axromPrgBank         = 0x07 // bits 0 - 2
+	axromOneScreenSelect = 0x10 // bit 4
+)
+
+func newAxROM(memory *memory) *axrom {
+	mapper := &axrom{baseMapper{memory: memory}}
+	mapper.mapPrgROM(0x8000, 32, 0)
+	mapper.mapChrMem(0x0000, 8, 0)
+	mapper.memory.ntMirroringType = ntMirroringOneScreenA
+
+	return mapper
+}
+
+func (mapper *axrom) WritePrgMemory(cpuAddress int, value int) {
+	if cpuAddress < 0x8000 {
+		mapper.baseMapper.WritePrgMemory(cpuAddress, value)
+		return
+	}
+
+	mapper.mapPrgROM(0x8000, 32, value&axromPrgBank)
+
+	if (value & axromOneScreenSelect) == 0 {
+		mapper.memory.ntMirroringType = ntMirroringOneScreenA
+	} else {
+		mapper.memory.ntMirroringType = ntMirroringOneScreenB
+	}
+}
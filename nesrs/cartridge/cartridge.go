@@ -2,6 +2,8 @@ package cartridge
 
 import (
 	"io"
+
+	"github.com/alpetkov/nesrs_go/nesrs/state"
 )
 
 // Memory for storing cartridge PRG and CHR ROM/RAM.
@@ -12,14 +14,15 @@ type memory struct {
 	chrMem                [][]int
 	isChrMemRAM           bool
 	ntMirroringType       int
+	ntExtraVRAM           [][]int // Name table VRAM (C + D)(2Kb) for four screen boards
 }
 
 // Cartridge for NES.
 type Cartridge struct {
 	memory       *memory
+	romInfo      *RomInfo
 	mapperNumber int
-	prgROMMap    [32]int
-	chrMemMap    [8]int
+	mapper       Mapper
 }
 
 func byteToInt(b []byte) []int {
@@ -32,175 +35,226 @@ func byteToInt(b []byte) []int {
 	return result
 }
 
+// splitBanks splits ROM data into 1KB banks.
+func splitBanks(b []byte) [][]int {
+	banks := make([][]int, len(b)/1024)
+	for i := range banks {
+		banks[i] = byteToInt(b[i*1024 : (i+1)*1024])
+	}
+
+	return banks
+}
+
 // New Cartridge.
-func New(reader io.Reader) *Cartridge {
+func New(reader io.Reader) (*Cartridge, error) {
 	// Headers
 	headers := make([]byte, 16)
-	reader.Read(headers)
+	if err := readSection(reader, headers, "header"); err != nil {
+		return nil, err
+	}
+	romInfo, err := NewRomInfo(headers)
+	if err != nil {
+		return nil, err
+	}
+	if !isMapperSupported(romInfo.MapperNumber) {
+		return nil, &UnsupportedMapperError{romInfo.MapperNumber}
+	}
 
 	// Read trainer
-	if headers[6]&0x04 != 0 {
+	if romInfo.HasTrainer {
 		trainer := make([]byte, 512)
-		reader.Read(trainer)
+		if err := readSection(reader, trainer, "trainer"); err != nil {
+			return nil, err
+		}
 	}
 
 	// Read PRG ROM
-	prgROM := make([][]int, int(headers[4])*16)
-	for i := range prgROM {
-		bank := make([]byte, 1024)
-		reader.Read(bank)
-		prgROM[i] = byteToInt(bank)
+	prgROMData := make([]byte, romInfo.PrgROMSize)
+	if err := readSection(reader, prgROMData, "PRG ROM"); err != nil {
+		return nil, err
 	}
+	prgROM := splitBanks(prgROMData)
 
 	// Read CHR ROM
 	var chrMem [][]int
 	isChrMemRAM := false
-	if headers[5] > 0 {
-		chrROM := make([][]int, int(headers[5])*8)
-		for i := range chrROM {
-			bank := make([]byte, 1024)
-			reader.Read(bank)
-			chrROM[i] = byteToInt(bank)
+	if romInfo.ChrROMSize > 0 {
+		chrROMData := make([]byte, romInfo.ChrROMSize)
+		if err := readSection(reader, chrROMData, "CHR ROM"); err != nil {
+			return nil, err
 		}
-		chrMem = chrROM
+		chrMem = splitBanks(chrROMData)
 	} else {
-		// CHR RAM
+		// CHR RAM (at least 8KB)
 		isChrMemRAM = true
-		chrMem = make([][]int, 8)
+		chrRAMSize := kilobytes(romInfo.ChrRAMSize + romInfo.ChrNVRAMSize)
+		if chrRAMSize < 8 {
+			chrRAMSize = 8
+		}
+		chrMem = make([][]int, chrRAMSize)
 		for i := range chrMem {
 			chrMem[i] = make([]int, 1024)
 		}
 	}
 
-	// PRG RAM
-	prgRAMSize := int(headers[8])
-	if prgRAMSize == 0 {
-		prgRAMSize = 1
+	// PRG RAM (at least 8KB, the whole $6000-$7FFF window)
+	prgRAMSize := romInfo.PrgRAMSize + romInfo.PrgNVRAMSize
+	if prgRAMSize < 8*1024 {
+		prgRAMSize = 8 * 1024
 	}
-	prgRAM := make([]int, prgRAMSize*8*1024)
+	prgRAM := make([]int, prgRAMSize)
 
 	// Mirror type
 	var ntMirroringType int
-	if (headers[6] & 0x8) != 0 {
+	if romInfo.IsFourScreen {
 		ntMirroringType = ntMirroringFourScreen
 	} else {
-		if (headers[6] & 0x1) == 0 {
+		if !romInfo.IsVerticalMirroring {
 			ntMirroringType = ntMirroringHorizontal
 		} else {
 			ntMirroringType = ntMirroringVertical
 		}
 	}
 
-	// Mapper number
-	isMapperNumberUpperNibbleSupported := true
-	for i := 11; i < len(headers); i++ {
-		if headers[i] != 0 {
-			isMapperNumberUpperNibbleSupported = false
-			break
+	// Four screen boards carry additional 2KB of name table VRAM.
+	var ntExtraVRAM [][]int
+	if ntMirroringType == ntMirroringFourScreen {
+		ntExtraVRAM = make([][]int, 2)
+		for i := range ntExtraVRAM {
+			ntExtraVRAM[i] = make([]int, 1024)
 		}
 	}
-	mapperNumber := int(((headers[6] & 0xF0) >> 4) & 0x0F)
-	if isMapperNumberUpperNibbleSupported {
-		mapperNumber += int(headers[7] & 0xF0)
+
+	memory := &memory{prgROM, prgRAM, romInfo.HasBattery, chrMem, isChrMemRAM, ntMirroringType, ntExtraVRAM}
+	cartridge := createCartridge(memory, romInfo)
+
+	return cartridge, nil
+}
+
+// kilobytes rounds size in bytes up to 1KB banks.
+func kilobytes(size int) int {
+	return (size + 1023) / 1024
+}
+
+func createCartridge(memory *memory, romInfo *RomInfo) *Cartridge {
+	cartridge := Cartridge{memory: memory, romInfo: romInfo, mapperNumber: romInfo.MapperNumber}
+	cartridge.mapper = newMapper(memory, cartridge.mapperNumber)
+
+	return &cartridge
+}
+
+// RomInfo of the loaded ROM.
+func (cartridge *Cartridge) RomInfo() RomInfo {
+	return *cartridge.romInfo
+}
+
+// IsBatteryBacked returns true when PRG RAM contents survive power off.
+func (cartridge *Cartridge) IsBatteryBacked() bool {
+	return cartridge.memory.isPrgRAMBatteryBacked
+}
+
+// SRAM returns a copy of battery-backed PRG RAM (nil if there is no battery).
+func (cartridge *Cartridge) SRAM() []byte {
+	if !cartridge.memory.isPrgRAMBatteryBacked {
+		return nil
 	}
 
-	memory := &memory{prgROM, prgRAM, false, chrMem, isChrMemRAM, ntMirroringType}
-	cartridge := createCartridge(memory, mapperNumber)
+	sram := make([]byte, len(cartridge.memory.prgRAM))
+	for i, value := range cartridge.memory.prgRAM {
+		sram[i] = byte(value)
+	}
 
-	return cartridge
+	return sram
 }
 
-func createCartridge(memory *memory, mapperNumber int) *Cartridge {
-	cartridge := Cartridge{memory: memory, mapperNumber: mapperNumber}
-	for i := 0; i < 32; i++ {
-		cartridge.prgROMMap[i] = i & (cap(memory.prgROM) - 1)
+// SetSRAM restores battery-backed PRG RAM. Data has to be of the RAM size.
+func (cartridge *Cartridge) SetSRAM(sram []byte) error {
+	if !cartridge.memory.isPrgRAMBatteryBacked {
+		return ErrNoBattery
+	}
+	if len(sram) != len(cartridge.memory.prgRAM) {
+		return ErrInvalidSize
 	}
 
-	for i := 0; i < 8; i++ {
-		cartridge.chrMemMap[i] = i & (cap(memory.chrMem) - 1)
+	for i, value := range sram {
+		cartridge.memory.prgRAM[i] = int(value)
 	}
 
-	return &cartridge
+	return nil
 }
 
-// ReadPrgMemory from Cartridge.
-func (cartridge *Cartridge) ReadPrgMemory(cpuAddress int) int {
-	page := (cpuAddress & 0xF000)
+// ReadSRAM restores battery-backed PRG RAM from reader (e.g. .sav file).
+func (cartridge *Cartridge) ReadSRAM(reader io.Reader) error {
+	if !cartridge.memory.isPrgRAMBatteryBacked {
+		return ErrNoBattery
+	}
 
-	if page == 0x4000 || page == 0x5000 {
-		// Expansion ROM
-		return cartridge.readExpansionRom(cpuAddress)
+	sram := make([]byte, len(cartridge.memory.prgRAM)+1)
+	n, err := io.ReadFull(reader, sram)
+	if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+		return err
+	}
 
-	} else if page == 0x6000 || page == 0x7000 {
-		// RAM
-		return cartridge.memory.prgRAM[cpuAddress&0x1FFF] // 8KB
+	return cartridge.SetSRAM(sram[:n])
+}
 
-	} else {
-		// ROM
-		return cartridge.memory.prgROM[cartridge.prgROMMap[(cpuAddress&0x7FFF)>>10]][(cpuAddress & 0x03FF)]
+// WriteSRAM writes battery-backed PRG RAM to writer (e.g. .sav file).
+func (cartridge *Cartridge) WriteSRAM(writer io.Writer) error {
+	if !cartridge.memory.isPrgRAMBatteryBacked {
+		return ErrNoBattery
 	}
+
+	_, err := writer.Write(cartridge.SRAM())
+	return err
+}
+
+// SetIRQReceiver for mappers which generate IRQs.
+func (cartridge *Cartridge) SetIRQReceiver(irqReceiver IRQReceiver) {
+	cartridge.mapper.SetIRQReceiver(irqReceiver)
+}
+
+// SaveState of cartridge RAM and board registers.
+func (cartridge *Cartridge) SaveState(writer *state.Writer) {
+	cartridge.mapper.SaveState(writer)
+}
+
+// LoadState of cartridge RAM and board registers.
+func (cartridge *Cartridge) LoadState(reader *state.Reader) {
+	cartridge.mapper.LoadState(reader)
+}
+
+// ObservePPUAddress lets the mapper watch PPU pattern table accesses (e.g. MMC3 scanline counter).
+// ppuCycle counts PPU cycles (wrapped by PPUCycleMask) for boards filtering short pulses.
+func (cartridge *Cartridge) ObservePPUAddress(ppuAddress int, ppuCycle int) {
+	cartridge.mapper.ObservePPUAddress(ppuAddress, ppuCycle)
+}
+
+// ReadPrgMemory from Cartridge.
+func (cartridge *Cartridge) ReadPrgMemory(cpuAddress int) int {
+	return cartridge.mapper.ReadPrgMemory(cpuAddress)
 }
 
 // WritePrgMemory to Cartridge.
 func (cartridge *Cartridge) WritePrgMemory(cpuAddress int, value int) {
-	page := (cpuAddress & 0xF000)
-
-	if page == 0x6000 || page == 0x7000 {
-		// RAM
-		cartridge.memory.prgRAM[cpuAddress&0x1FFF] = value
-	}
+	cartridge.mapper.WritePrgMemory(cpuAddress, value)
 }
 
 // ReadChrMemory from Cartridge.
 func (cartridge *Cartridge) ReadChrMemory(ppuAddress int) int {
-	if 0x0000 <= ppuAddress && ppuAddress <= 0x1FFF {
-		return cartridge.memory.chrMem[cartridge.chrMemMap[(ppuAddress&0x1FFF)>>10]][ppuAddress&0x03FF]
-	}
-
-	return 0
+	return cartridge.mapper.ReadChrMemory(ppuAddress)
 }
 
 // WriteChrMemory to Cartridge.
 func (cartridge *Cartridge) WriteChrMemory(ppuAddress int, value int) {
-	if 0x0000 <= ppuAddress && ppuAddress <= 0x1FFF {
-		if cartridge.memory.isChrMemRAM {
-			cartridge.memory.chrMem[cartridge.chrMemMap[(ppuAddress&0x1FFF)>>10]][ppuAddress&0x03FF] = value
-		}
-	}
+	cartridge.mapper.WriteChrMemory(ppuAddress, value)
 }
 
 // ReadNameTable from Cartridge.
 func (cartridge *Cartridge) ReadNameTable(ppuAddress int, ppuNTRAM [][]int) int {
-	ntIndex := getNameTableIndex(ppuAddress, cartridge.memory.ntMirroringType)
-	nameTableOffset := getNameTableOffset(ppuAddress)
-
-	switch ntIndex {
-	case ntIndexA:
-		return ppuNTRAM[0][nameTableOffset]
-	case ntIndexB:
-		return ppuNTRAM[1][nameTableOffset]
-	case ntIndexC:
-		return 0
-	case ntIndexD:
-		return 0
-	}
-
-	return 0
+	return cartridge.mapper.ReadNameTable(ppuAddress, ppuNTRAM)
 }
 
 // WriteNameTable to Cartridge.
 func (cartridge *Cartridge) WriteNameTable(ppuAddress int, value int, ppuNTRAM [][]int) {
-	ntIndex := getNameTableIndex(ppuAddress, cartridge.memory.ntMirroringType)
-	nameTableOffset := getNameTableOffset(ppuAddress)
-
-	switch ntIndex {
-	case ntIndexA:
-		ppuNTRAM[0][nameTableOffset] = value
-	case ntIndexB:
-		ppuNTRAM[1][nameTableOffset] = value
-	}
-}
-
-func (cartridge *Cartridge) readExpansionRom(cpuAddress int) int {
-	return 0
+	cartridge.mapper.WriteNameTable(ppuAddress, value, ppuNTRAM)
 }
@@ -0,0 +1,168 @@
+package cartridge
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/alpetkov/nesrs_go/nesrs/state"
+)
+
+func TestNewErrors(t *testing.T) {
+	nrom := func(mapperNumber byte, size int) []byte {
+		rom := []byte{'N', 'E', 'S', 0x1A, 0x01, 0x01, mapperNumber << 4, 0, 0, 0, 0, 0, 0, 0, 0, 0}
+		return append(rom, make([]byte, size)...)
+	}
+
+	data := []struct {
+		name  string
+		rom   []byte
+		check func(err error) bool
+	}{
+		{"valid", nrom(0, 24*1024), func(err error) bool {
+			return err == nil
+		}},
+		{"magic", append([]byte("NES\x00"), nrom(0, 24*1024)[4:]...), func(err error) bool {
+			return err == ErrInvalidMagic
+		}},
+		{"empty", nil, func(err error) bool {
+			truncatedErr, ok := err.(*TruncatedError)
+			return ok && truncatedErr.Section == "header"
+		}},
+		{"truncated CHR", nrom(0, 20*1024), func(err error) bool {
+			truncatedErr, ok := err.(*TruncatedError)
+			return ok && truncatedErr.Section == "CHR ROM" && errors.Is(err, io.ErrUnexpectedEOF)
+		}},
+		{"no PRG", append([]byte{'N', 'E', 'S', 0x1A, 0, 1}, make([]byte, 10+8*1024)...), func(err error) bool {
+			return err == ErrInvalidSize
+		}},
+		{"NES 2.0 PRG size overflow", []byte{'N', 'E', 'S', 0x1A, 0xFC, 0x01, 0, 0x08, 0, 0x0F, 0, 0, 0, 0, 0, 0}, func(err error) bool {
+			return err == ErrInvalidSize
+		}},
+		{"NES 2.0 CHR size overflow", []byte{'N', 'E', 'S', 0x1A, 0x01, 0xFC, 0, 0x08, 0, 0xF0, 0, 0, 0, 0, 0, 0}, func(err error) bool {
+			return err == ErrInvalidSize
+		}},
+		{"mapper", nrom(15, 24*1024), func(err error) bool {
+			mapperErr, ok := err.(*UnsupportedMapperError)
+			return ok && mapperErr.MapperNumber == 15
+		}},
+	}
+
+	for _, tt := range data {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := New(bytes.NewReader(tt.rom))
+			if !tt.check(err) {
+				t.Errorf("Unexpected error %v", err)
+			}
+		})
+	}
+}
+
+func TestFourScreenNameTables(t *testing.T) {
+	rom := []byte{'N', 'E', 'S', 0x1A, 0x01, 0x01, 0x08, 0, 0, 0, 0, 0, 0, 0, 0, 0}
+	rom = append(rom, make([]byte, 24*1024)...)
+
+	cartridge, err := New(bytes.NewReader(rom))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ppuNTRAM := [][]int{make([]int, 1024), make([]int, 1024)}
+	addresses := []int{0x2000, 0x2405, 0x2805, 0x2C05}
+	for i, address := range addresses {
+		cartridge.WriteNameTable(address, i+1, ppuNTRAM)
+	}
+
+	for i, address := range addresses {
+		if value := cartridge.ReadNameTable(address, ppuNTRAM); value != i+1 {
+			t.Errorf("Name table at %04X: wrong %v, right %v", address, value, i+1)
+		}
+	}
+}
+
+func TestSRAM(t *testing.T) {
+	rom := []byte{'N', 'E', 'S', 0x1A, 0x01, 0x01, 0x02, 0, 0, 0, 0, 0, 0, 0, 0, 0}
+	rom = append(rom, make([]byte, 24*1024)...)
+	cartridge, err := New(bytes.NewReader(rom))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !cartridge.IsBatteryBacked() {
+		t.Fatal("Battery flag ignored")
+	}
+
+	// Round trip
+	cartridge.WritePrgMemory(0x6000, 0x12)
+	cartridge.WritePrgMemory(0x7FFF, 0x34)
+	var sav bytes.Buffer
+	if err := cartridge.WriteSRAM(&sav); err != nil {
+		t.Fatal(err)
+	}
+	if sav.Len() != 8*1024 || sav.Bytes()[0] != 0x12 || sav.Bytes()[8*1024-1] != 0x34 {
+		t.Fatalf("Wrong SRAM (%v bytes)", sav.Len())
+	}
+
+	other, _ := New(bytes.NewReader(rom))
+	if err := other.ReadSRAM(bytes.NewReader(sav.Bytes())); err != nil {
+		t.Fatal(err)
+	}
+	if other.ReadPrgMemory(0x6000) != 0x12 || other.ReadPrgMemory(0x7FFF) != 0x34 {
+		t.Errorf("SRAM not restored")
+	}
+
+	// Wrong sizes leave SRAM unchanged
+	for _, size := range []int{0, 8*1024 - 1, 8*1024 + 1} {
+		if err := other.ReadSRAM(bytes.NewReader(make([]byte, size))); err != ErrInvalidSize {
+			t.Errorf("Size %v: got %v, want %v", size, err, ErrInvalidSize)
+		}
+	}
+	if other.ReadPrgMemory(0x6000) != 0x12 {
+		t.Errorf("SRAM changed by failed read")
+	}
+
+	// No battery
+	rom[6] = 0x00
+	noBattery, _ := New(bytes.NewReader(rom))
+	if noBattery.SRAM() != nil {
+		t.Errorf("SRAM without battery")
+	}
+	if err := noBattery.ReadSRAM(bytes.NewReader(sav.Bytes())); err != ErrNoBattery {
+		t.Errorf("ReadSRAM: got %v, want %v", err, ErrNoBattery)
+	}
+	if err := noBattery.WriteSRAM(new(bytes.Buffer)); err != ErrNoBattery {
+		t.Errorf("WriteSRAM: got %v, want %v", err, ErrNoBattery)
+	}
+}
+
+func TestLoadStateErrors(t *testing.T) {
+	data := []struct {
+		name         string
+		mapperNumber int
+		corrupt      func(cartridge *Cartridge)
+	}{
+		{"mirroring", mapperNROM, func(cartridge *Cartridge) { cartridge.memory.ntMirroringType = 5 }},
+		{"four screen without VRAM", mapperNROM, func(cartridge *Cartridge) {
+			cartridge.memory.ntMirroringType = ntMirroringFourScreen
+		}},
+		{"PRG bank", mapperUxROM, func(cartridge *Cartridge) { cartridge.mapper.(*uxrom).prgROMMap[0] = 32 }},
+		{"MMC1 shift count", mapperMMC1, func(cartridge *Cartridge) { cartridge.mapper.(*mmc1).shiftCount = 5 }},
+		{"MMC3 bank", mapperMMC3, func(cartridge *Cartridge) { cartridge.mapper.(*mmc3).bankRegs[6] = -1 }},
+	}
+
+	for _, tt := range data {
+		t.Run(tt.name, func(t *testing.T) {
+			cartridge := newTestCartridge(t, tt.mapperNumber, 32, 8)
+			tt.corrupt(cartridge)
+			var snapshot bytes.Buffer
+			cartridge.SaveState(state.NewWriter(&snapshot))
+
+			loaded := newTestCartridge(t, tt.mapperNumber, 32, 8)
+			reader := state.NewReader(&snapshot)
+			loaded.LoadState(reader)
+			if reader.Err() != state.ErrInvalidValue {
+				t.Errorf("Got %v, expected %v", reader.Err(), state.ErrInvalidValue)
+			}
+		})
+	}
+}
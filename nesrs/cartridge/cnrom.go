@@ -0,0 +1,23 @@
+package cartridge
+
+// CNROM (mapper 3). Fixed PRG ROM, switchable 8KB CHR ROM bank.
+type cnrom struct {
+	baseMapper
+}
+
+func newCNROM(memory *memory) *cnrom {
+	mapper := &cnrom{baseMapper{memory: memory}}
+	mapper.mapPrgROM(0x8000, 32, 0)
+	mapper.mapChrMem(0x0000, 8, 0)
+
+	return mapper
+}
+
+func (mapper *cnrom) WritePrgMemory(cpuAddress int, value int) {
+	if cpuAddress < 0x8000 {
+		mapper.baseMapper.WritePrgMemory(cpuAddress, value)
+		return
+	}
+
+	mapper.mapChrMem(0x0000, 8, value)
+}
@@ -0,0 +1,47 @@
+package cartridge
+
+import (
+	"errors"
+	"fmt"
+	"io"
+)
+
+// Errors returned while loading a ROM or SRAM.
+var (
+	ErrInvalidMagic = errors.New("cartridge: missing \"NES\\x1A\" magic")
+	ErrInvalidSize  = errors.New("cartridge: impossible ROM/RAM size")
+	ErrNoBattery    = errors.New("cartridge: PRG RAM is not battery-backed")
+)
+
+// TruncatedError - ROM data ended before the size declared in the header.
+type TruncatedError struct {
+	Section string // header, trainer, PRG ROM or CHR ROM
+}
+
+func (err *TruncatedError) Error() string {
+	return fmt.Sprintf("cartridge: truncated %s", err.Section)
+}
+
+// Unwrap so that errors.Is(err, io.ErrUnexpectedEOF) holds.
+func (err *TruncatedError) Unwrap() error {
+	return io.ErrUnexpectedEOF
+}
+
+// UnsupportedMapperError - ROM needs a mapper which is not implemented.
+type UnsupportedMapperError struct {
+	MapperNumber int
+}
+
+func (err *UnsupportedMapperError) Error() string {
+	return fmt.Sprintf("cartridge: unsupported mapper %d", err.MapperNumber)
+}
+
+// readSection fills buffer completely or returns TruncatedError.
+func readSection(reader io.Reader, buffer []byte, section string) error {
+	_, err := io.ReadFull(reader, buffer)
+	if err == io.EOF || err == io.ErrUnexpectedEOF {
+		return &TruncatedError{section}
+	}
+
+	return err
+}
@@ -0,0 +1,29 @@
+package cartridge
+
+// GxROM (mapper 66). Switchable 32KB PRG ROM and 8KB CHR ROM banks.
+type gxrom struct {
+	baseMapper
+}
+
+const (
+	gxromChrBank = 0x03 // bits 0 & 1
+	gxromPrgBank = 0x30 // bits 4 & 5
+)
+
+func newGxROM(memory *memory) *gxrom {
+	mapper := &gxrom{baseMapper{memory: memory}}
+	mapper.mapPrgROM(0x8000, 32, 0)
+	mapper.mapChrMem(0x0000, 8, 0)
+
+	return mapper
+}
+
+func (mapper *gxrom) WritePrgMemory(cpuAddress int, value int) {
+	if cpuAddress < 0x8000 {
+		mapper.baseMapper.WritePrgMemory(cpuAddress, value)
+		return
+	}
+
+	mapper.mapPrgROM(0x8000, 32, (value&gxromPrgBank)>>4)
+	mapper.mapChrMem(0x0000, 8, value&gxromChrBank)
+}
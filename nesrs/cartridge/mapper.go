@@ -0,0 +1,194 @@
+package cartridge
+
+import (
+	"github.com/alpetkov/nesrs_go/nesrs/state"
+)
+
+// Mapper - cartridge board logic. Handles PRG/CHR memory bank switching and name table mirroring.
+type Mapper interface {
+	ReadPrgMemory(cpuAddress int) int
+	WritePrgMemory(cpuAddress int, value int)
+	ReadChrMemory(ppuAddress int) int
+	WriteChrMemory(ppuAddress int, value int)
+	ReadNameTable(ppuAddress int, ppuNTRAM [][]int) int
+	WriteNameTable(ppuAddress int, value int, ppuNTRAM [][]int)
+	ObservePPUAddress(ppuAddress int, ppuCycle int)
+	SetIRQReceiver(irqReceiver IRQReceiver)
+	SaveState(writer *state.Writer)
+	LoadState(reader *state.Reader)
+}
+
+// PPUCycleMask - PPU cycle counter passed to ObservePPUAddress wraps around within the mask.
+const PPUCycleMask = 1<<30 - 1
+
+// IRQReceiver - handles cartridge IRQ line changes.
+type IRQReceiver interface {
+	ReceiveIRQ(asserted bool)
+}
+
+// iNES mapper numbers.
+const (
+	mapperNROM  = 0
+	mapperMMC1  = 1
+	mapperUxROM = 2
+	mapperCNROM = 3
+	mapperMMC3  = 4
+	mapperAxROM = 7
+	mapperGxROM = 66
+)
+
+func isMapperSupported(mapperNumber int) bool {
+	switch mapperNumber {
+	case mapperNROM, mapperMMC1, mapperUxROM, mapperCNROM, mapperMMC3, mapperAxROM, mapperGxROM:
+		return true
+	}
+
+	return false
+}
+
+func newMapper(memory *memory, mapperNumber int) Mapper {
+	switch mapperNumber {
+	case mapperMMC1:
+		return newMMC1(memory)
+	case mapperUxROM:
+		return newUxROM(memory)
+	case mapperCNROM:
+		return newCNROM(memory)
+	case mapperMMC3:
+		return newMMC3(memory)
+	case mapperAxROM:
+		return newAxROM(memory)
+	case mapperGxROM:
+		return newGxROM(memory)
+	}
+
+	return newNROM(memory)
+}
+
+// Common mapper logic. PRG ROM and CHR memory are accessed through 1KB bank maps.
+type baseMapper struct {
+	memory      *memory
+	prgROMMap   [32]int // $8000 - $FFFF
+	chrMemMap   [8]int  // $0000 - $1FFF
+	irqReceiver IRQReceiver
+}
+
+// mapPrgROM maps PRG ROM bank with the given size (in KB) at the CPU address.
+func (mapper *baseMapper) mapPrgROM(cpuAddress int, sizeKB int, bank int) {
+	slot := (cpuAddress & 0x7FFF) >> 10
+	for i := 0; i < sizeKB; i++ {
+		mapper.prgROMMap[slot+i] = (bank*sizeKB + i) % len(mapper.memory.prgROM)
+	}
+}
+
+// mapChrMem maps CHR ROM/RAM bank with the given size (in KB) at the PPU address.
+func (mapper *baseMapper) mapChrMem(ppuAddress int, sizeKB int, bank int) {
+	slot := (ppuAddress & 0x1FFF) >> 10
+	for i := 0; i < sizeKB; i++ {
+		mapper.chrMemMap[slot+i] = (bank*sizeKB + i) % len(mapper.memory.chrMem)
+	}
+}
+
+// prgROMBanksCount with the given size (in KB).
+func (mapper *baseMapper) prgROMBanksCount(sizeKB int) int {
+	count := len(mapper.memory.prgROM) / sizeKB
+	if count == 0 {
+		count = 1
+	}
+
+	return count
+}
+
+func (mapper *baseMapper) setIRQ(asserted bool) {
+	if mapper.irqReceiver != nil {
+		mapper.irqReceiver.ReceiveIRQ(asserted)
+	}
+}
+
+func (mapper *baseMapper) SetIRQReceiver(irqReceiver IRQReceiver) {
+	mapper.irqReceiver = irqReceiver
+}
+
+func (mapper *baseMapper) ObservePPUAddress(ppuAddress int, ppuCycle int) {
+	// Most boards don't care about PPU address bus.
+}
+
+func (mapper *baseMapper) ReadPrgMemory(cpuAddress int) int {
+	page := (cpuAddress & 0xF000)
+
+	if page == 0x4000 || page == 0x5000 {
+		// Expansion ROM
+		return mapper.readExpansionRom(cpuAddress)
+
+	} else if page == 0x6000 || page == 0x7000 {
+		// RAM
+		return mapper.memory.prgRAM[cpuAddress&0x1FFF] // 8KB
+
+	} else {
+		// ROM
+		return mapper.memory.prgROM[mapper.prgROMMap[(cpuAddress&0x7FFF)>>10]][(cpuAddress & 0x03FF)]
+	}
+}
+
+func (mapper *baseMapper) WritePrgMemory(cpuAddress int, value int) {
+	page := (cpuAddress & 0xF000)
+
+	if page == 0x6000 || page == 0x7000 {
+		// RAM
+		mapper.memory.prgRAM[cpuAddress&0x1FFF] = value
+	}
+}
+
+func (mapper *baseMapper) ReadChrMemory(ppuAddress int) int {
+	if 0x0000 <= ppuAddress && ppuAddress <= 0x1FFF {
+		return mapper.memory.chrMem[mapper.chrMemMap[(ppuAddress&0x1FFF)>>10]][ppuAddress&0x03FF]
+	}
+
+	return 0
+}
+
+func (mapper *baseMapper) WriteChrMemory(ppuAddress int, value int) {
+	if 0x0000 <= ppuAddress && ppuAddress <= 0x1FFF {
+		if mapper.memory.isChrMemRAM {
+			mapper.memory.chrMem[mapper.chrMemMap[(ppuAddress&0x1FFF)>>10]][ppuAddress&0x03FF] = value
+		}
+	}
+}
+
+func (mapper *baseMapper) ReadNameTable(ppuAddress int, ppuNTRAM [][]int) int {
+	ntIndex := getNameTableIndex(ppuAddress, mapper.memory.ntMirroringType)
+	nameTableOffset := getNameTableOffset(ppuAddress)
+
+	switch ntIndex {
+	case ntIndexA:
+		return ppuNTRAM[0][nameTableOffset]
+	case ntIndexB:
+		return ppuNTRAM[1][nameTableOffset]
+	case ntIndexC:
+		return mapper.memory.ntExtraVRAM[0][nameTableOffset]
+	case ntIndexD:
+		return mapper.memory.ntExtraVRAM[1][nameTableOffset]
+	}
+
+	return 0
+}
+
+func (mapper *baseMapper) WriteNameTable(ppuAddress int, value int, ppuNTRAM [][]int) {
+	ntIndex := getNameTableIndex(ppuAddress, mapper.memory.ntMirroringType)
+	nameTableOffset := getNameTableOffset(ppuAddress)
+
+	switch ntIndex {
+	case ntIndexA:
+		ppuNTRAM[0][nameTableOffset] = value
+	case ntIndexB:
+		ppuNTRAM[1][nameTableOffset] = value
+	case ntIndexC:
+		mapper.memory.ntExtraVRAM[0][nameTableOffset] = value
+	case ntIndexD:
+		mapper.memory.ntExtraVRAM[1][nameTableOffset] = value
+	}
+}
+
+func (mapper *baseMapper) readExpansionRom(cpuAddress int) int {
+	return 0
+}
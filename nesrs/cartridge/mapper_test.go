@@ -0,0 +1,160 @@
+package cartridge
+
+import (
+	"bytes"
+	"testing"
+)
+
+// newTestCartridge with every 1KB of PRG ROM and CHR ROM tagged by its 1KB bank number.
+func newTestCartridge(t *testing.T, mapperNumber int, prgKB int, chrKB int) *Cartridge {
+	rom := []byte{'N', 'E', 'S', 0x1A, byte(prgKB / 16), byte(chrKB / 8),
+		byte(mapperNumber&0x0F) << 4, byte(mapperNumber & 0xF0), 0, 0, 0, 0, 0, 0, 0, 0}
+	for _, sizeKB := range []int{prgKB, chrKB} {
+		for bank := 0; bank < sizeKB; bank++ {
+			data := make([]byte, 1024)
+			data[0], data[1] = byte(bank), byte(bank>>8)
+			rom = append(rom, data...)
+		}
+	}
+
+	cartridge, err := New(bytes.NewReader(rom))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return cartridge
+}
+
+// prgBank mapped at the CPU address (in banks of the given size).
+func prgBank(cartridge *Cartridge, cpuAddress int, sizeKB int) int {
+	return (cartridge.ReadPrgMemory(cpuAddress) | cartridge.ReadPrgMemory(cpuAddress+1)<<8) / sizeKB
+}
+
+// chrBank mapped at the PPU address (in banks of the given size).
+func chrBank(cartridge *Cartridge, ppuAddress int, sizeKB int) int {
+	return (cartridge.ReadChrMemory(ppuAddress) | cartridge.ReadChrMemory(ppuAddress+1)<<8) / sizeKB
+}
+
+// writeMMC1 loads the register at the address through the serial port, LSB first.
+func writeMMC1(cartridge *Cartridge, cpuAddress int, value int) {
+	for i := uint(0); i < 5; i++ {
+		cartridge.WritePrgMemory(cpuAddress, (value>>i)&0x01)
+	}
+}
+
+func TestMMC1(t *testing.T) {
+	type write struct {
+		address int
+		value   int // Loaded serially, $80 is a single reset write
+	}
+
+	data := []struct {
+		name      string
+		prgKB     int
+		writes    []write
+		prg       [2]int // 16KB banks at $8000 & $C000
+		chr       [2]int // 4KB banks at $0000 & $1000
+		mirroring int
+	}{
+		{"power on", 256, nil, [2]int{0, 15}, [2]int{0, 1}, ntMirroringOneScreenA},
+		{"PRG mode 3", 256, []write{{0x8000, 0x0F}, {0xE000, 5}}, [2]int{5, 15}, [2]int{0, 1}, ntMirroringHorizontal},
+		{"PRG mode 2", 256, []write{{0x8000, 0x0A}, {0xE000, 5}}, [2]int{0, 5}, [2]int{0, 1}, ntMirroringVertical},
+		{"PRG mode 0", 256, []write{{0x9FFF, 0x01}, {0xE000, 5}}, [2]int{4, 5}, [2]int{0, 1}, ntMirroringOneScreenB},
+		{"CHR 8KB", 256, []write{{0x8000, 0x0C}, {0xA000, 5}, {0xC000, 9}}, [2]int{0, 15}, [2]int{4, 5}, ntMirroringOneScreenA},
+		{"CHR 4KB", 256, []write{{0x8000, 0x1C}, {0xA000, 5}, {0xC000, 9}}, [2]int{0, 15}, [2]int{5, 9}, ntMirroringOneScreenA},
+		{"512KB outer bank", 512, []write{{0x8000, 0x0C}, {0xA000, 0x10}, {0xE000, 2}}, [2]int{18, 31}, [2]int{16, 17}, ntMirroringOneScreenA},
+		{"512KB PRG mode 2", 512, []write{{0x8000, 0x08}, {0xA000, 0x10}, {0xE000, 2}}, [2]int{16, 18}, [2]int{16, 17}, ntMirroringOneScreenA},
+		{"reset locks PRG mode 3", 256, []write{{0x8000, 0x0A}, {0xE000, 5}, {0x8000, 0x80}}, [2]int{5, 15}, [2]int{0, 1}, ntMirroringVertical},
+	}
+
+	for _, tt := range data {
+		t.Run(tt.name, func(t *testing.T) {
+			cartridge := newTestCartridge(t, mapperMMC1, tt.prgKB, 128)
+			for _, w := range tt.writes {
+				if w.value == 0x80 {
+					cartridge.WritePrgMemory(w.address, w.value)
+				} else {
+					writeMMC1(cartridge, w.address, w.value)
+				}
+			}
+
+			prg := [2]int{prgBank(cartridge, 0x8000, 16), prgBank(cartridge, 0xC000, 16)}
+			chr := [2]int{chrBank(cartridge, 0x0000, 4), chrBank(cartridge, 0x1000, 4)}
+			if prg != tt.prg || chr != tt.chr {
+				t.Errorf("PRG %v, CHR %v, expected PRG %v, CHR %v", prg, chr, tt.prg, tt.chr)
+			}
+			if cartridge.memory.ntMirroringType != tt.mirroring {
+				t.Errorf("Mirroring %v, expected %v", cartridge.memory.ntMirroringType, tt.mirroring)
+			}
+		})
+	}
+}
+
+func TestMMC1ShiftRegisterReset(t *testing.T) {
+	cartridge := newTestCartridge(t, mapperMMC1, 256, 128)
+
+	// Reset drops partially loaded bits, next five writes load a register
+	cartridge.WritePrgMemory(0xE000, 1)
+	cartridge.WritePrgMemory(0xE000, 1)
+	cartridge.WritePrgMemory(0xE000, 0x80)
+	writeMMC1(cartridge, 0xE000, 3)
+	if bank := prgBank(cartridge, 0x8000, 16); bank != 3 {
+		t.Errorf("PRG bank %v, expected 3", bank)
+	}
+
+	// PRG RAM disable
+	cartridge.WritePrgMemory(0x6000, 0x42)
+	writeMMC1(cartridge, 0xE000, 0x13)
+	if value := cartridge.ReadPrgMemory(0x6000); value != 0 {
+		t.Errorf("Disabled PRG RAM read %02X", value)
+	}
+	writeMMC1(cartridge, 0xE000, 0x03)
+	if value := cartridge.ReadPrgMemory(0x6000); value != 0x42 {
+		t.Errorf("PRG RAM read %02X", value)
+	}
+}
+
+func TestDiscreteMappers(t *testing.T) {
+	data := []struct {
+		name         string
+		mapperNumber int
+		prgKB        int
+		chrKB        int
+		value        int    // Written at $8000
+		prg          [2]int // 16KB banks at $8000 & $C000
+		chr          int    // 8KB bank at $0000
+		mirroring    int
+	}{
+		{"UxROM power on", mapperUxROM, 128, 0, -1, [2]int{0, 7}, 0, ntMirroringHorizontal},
+		{"UxROM", mapperUxROM, 128, 0, 3, [2]int{3, 7}, 0, ntMirroringHorizontal},
+		{"CNROM power on", mapperCNROM, 32, 32, -1, [2]int{0, 1}, 0, ntMirroringHorizontal},
+		{"CNROM", mapperCNROM, 32, 32, 2, [2]int{0, 1}, 2, ntMirroringHorizontal},
+		{"AxROM power on", mapperAxROM, 256, 0, -1, [2]int{0, 1}, 0, ntMirroringOneScreenA},
+		{"AxROM", mapperAxROM, 256, 0, 0x05, [2]int{10, 11}, 0, ntMirroringOneScreenA},
+		{"AxROM screen B", mapperAxROM, 256, 0, 0x13, [2]int{6, 7}, 0, ntMirroringOneScreenB},
+		{"GxROM power on", mapperGxROM, 128, 32, -1, [2]int{0, 1}, 0, ntMirroringHorizontal},
+		{"GxROM", mapperGxROM, 128, 32, 0x21, [2]int{4, 5}, 1, ntMirroringHorizontal},
+	}
+
+	for _, tt := range data {
+		t.Run(tt.name, func(t *testing.T) {
+			cartridge := newTestCartridge(t, tt.mapperNumber, tt.prgKB, tt.chrKB)
+			if tt.value >= 0 {
+				cartridge.WritePrgMemory(0x8000, tt.value)
+			}
+
+			prg := [2]int{prgBank(cartridge, 0x8000, 16), prgBank(cartridge, 0xC000, 16)}
+			if prg != tt.prg {
+				t.Errorf("PRG %v, expected %v", prg, tt.prg)
+			}
+			if tt.chrKB > 0 {
+				if chr := chrBank(cartridge, 0x0000, 8); chr != tt.chr {
+					t.Errorf("CHR %v, expected %v", chr, tt.chr)
+				}
+			}
+			if cartridge.memory.ntMirroringType != tt.mirroring {
+				t.Errorf("Mirroring %v, expected %v", cartridge.memory.ntMirroringType, tt.mirroring)
+			}
+		})
+	}
+}
@@ -0,0 +1,126 @@
+package cartridge
+
+// MMC1 (mapper 1). Registers are loaded serially through a 5 bit shift register.
+type mmc1 struct {
+	baseMapper
+	shiftRegister int
+	shiftCount    int
+	controlReg    int // $8000-$9FFF: CPPMM (CHR mode, PRG mode, mirroring)
+	chrBank0Reg   int // $A000-$BFFF
+	chrBank1Reg   int // $C000-$DFFF
+	prgBankReg    int // $E000-$FFFF: RPPPP (PRG RAM disable, PRG bank)
+}
+
+const (
+	mmc1ControlMirroring = 0x03 // bits 0 & 1
+	mmc1ControlPrgMode   = 0x0C // bits 2 & 3
+	mmc1ControlChrMode   = 0x10 // bit 4
+	mmc1PrgBank          = 0x0F // bits 0 - 3
+	mmc1PrgRAMDisable    = 0x10 // bit 4
+	mmc1PrgOuterBank     = 0x10 // bit 4 of CHR bank 0 (SUROM 512KB PRG)
+)
+
+func newMMC1(memory *memory) *mmc1 {
+	// Power up in PRG mode 3 (last bank fixed at $C000).
+	mapper := &mmc1{baseMapper: baseMapper{memory: memory}, controlReg: mmc1ControlPrgMode}
+	mapper.updateBanks()
+
+	return mapper
+}
+
+func (mapper *mmc1) ReadPrgMemory(cpuAddress int) int {
+	if (cpuAddress&0xE000) == 0x6000 && !mapper.isPrgRAMEnabled() {
+		return 0
+	}
+
+	return mapper.baseMapper.ReadPrgMemory(cpuAddress)
+}
+
+func (mapper *mmc1) WritePrgMemory(cpuAddress int, value int) {
+	if cpuAddress < 0x8000 {
+		if (cpuAddress&0xE000) != 0x6000 || mapper.isPrgRAMEnabled() {
+			mapper.baseMapper.WritePrgMemory(cpuAddress, value)
+		}
+		return
+	}
+
+	if (value & 0x80) != 0 {
+		// Reset shift register and lock PRG mode 3.
+		mapper.shiftRegister = 0
+		mapper.shiftCount = 0
+		mapper.controlReg |= mmc1ControlPrgMode
+		mapper.updateBanks()
+		return
+	}
+
+	// Bits are shifted in LSB first.
+	mapper.shiftRegister |= (value & 0x01) << uint(mapper.shiftCount)
+	mapper.shiftCount++
+
+	if mapper.shiftCount == 5 {
+		// Fifth write selects the register by address bits 13 & 14.
+		switch (cpuAddress >> 13) & 0x03 {
+		case 0:
+			mapper.controlReg = mapper.shiftRegister
+		case 1:
+			mapper.chrBank0Reg = mapper.shiftRegister
+		case 2:
+			mapper.chrBank1Reg = mapper.shiftRegister
+		case 3:
+			mapper.prgBankReg = mapper.shiftRegister
+		}
+
+		mapper.shiftRegister = 0
+		mapper.shiftCount = 0
+		mapper.updateBanks()
+	}
+}
+
+func (mapper *mmc1) isPrgRAMEnabled() bool {
+	return (mapper.prgBankReg & mmc1PrgRAMDisable) == 0
+}
+
+func (mapper *mmc1) updateBanks() {
+	// Mirroring
+	switch mapper.controlReg & mmc1ControlMirroring {
+	case 0:
+		mapper.memory.ntMirroringType = ntMirroringOneScreenA
+	case 1:
+		mapper.memory.ntMirroringType = ntMirroringOneScreenB
+	case 2:
+		mapper.memory.ntMirroringType = ntMirroringVertical
+	case 3:
+		mapper.memory.ntMirroringType = ntMirroringHorizontal
+	}
+
+	// PRG ROM (16KB banks). 512KB boards use CHR bank 0 bit 4 to select the 256KB half.
+	prgOuterBank := 0
+	if len(mapper.memory.prgROM) > 256 {
+		prgOuterBank = mapper.chrBank0Reg & mmc1PrgOuterBank
+	}
+	prgBank := prgOuterBank | (mapper.prgBankReg & mmc1PrgBank)
+
+	switch (mapper.controlReg & mmc1ControlPrgMode) >> 2 {
+	case 0, 1:
+		// Switch 32KB at $8000, ignore low bit of bank number.
+		mapper.mapPrgROM(0x8000, 32, prgBank>>1)
+	case 2:
+		// Fix first bank at $8000 and switch 16KB bank at $C000.
+		mapper.mapPrgROM(0x8000, 16, prgOuterBank)
+		mapper.mapPrgROM(0xC000, 16, prgBank)
+	case 3:
+		// Fix last bank at $C000 and switch 16KB bank at $8000.
+		mapper.mapPrgROM(0x8000, 16, prgBank)
+		mapper.mapPrgROM(0xC000, 16, prgOuterBank|mmc1PrgBank)
+	}
+
+	// CHR ROM/RAM
+	if (mapper.controlReg & mmc1ControlChrMode) == 0 {
+		// Switch 8KB at a time, ignore low bit of bank number.
+		mapper.mapChrMem(0x0000, 8, mapper.chrBank0Reg>>1)
+	} else {
+		// Switch two separate 4KB banks.
+		mapper.mapChrMem(0x0000, 4, mapper.chrBank0Reg)
+		mapper.mapChrMem(0x1000, 4, mapper.chrBank1Reg)
+	}
+}
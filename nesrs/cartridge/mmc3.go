@@ -0,0 +1,156 @@
+package cartridge
+
+// MMC3 (mapper 4). 8KB PRG banks, 1KB/2KB CHR banks and a scanline IRQ counter
+// clocked by rising edges of PPU address line A12.
+type mmc3 struct {
+	baseMapper
+	bankSelectReg    int    // $8000-$9FFE (even): CPxx xRRR (CHR A12 inversion, PRG mode, target register)
+	bankRegs         [8]int // $8001-$9FFF (odd): R0 - R7
+	prgRAMProtectReg int    // $A001-$BFFF (odd): EWxx xxxx (enable, write protect)
+	irqLatch         int    // $C000-$DFFE (even)
+	irqCounter       int    //
+	irqReload        bool   // $C001-$DFFF (odd)
+	irqEnabled       bool   // $E000-$FFFE (even) disable, $E001-$FFFF (odd) enable
+	isA12High        bool   // Last seen PPU A12 state
+	a12LowCycle      int    // PPU cycle when A12 went low
+	isFourScreen     bool   // Hardwired four screen boards ignore mirroring writes
+}
+
+const (
+	mmc3BankSelectTarget    = 0x07 // bits 0 - 2
+	mmc3BankSelectPrgMode   = 0x40 // bit 6
+	mmc3BankSelectChrInvert = 0x80 // bit 7
+	mmc3PrgRAMWriteProtect  = 0x40 // bit 6
+	mmc3PrgRAMEnable        = 0x80 // bit 7
+
+	// A12 has to stay low for about 3 CPU (M2) cycles before a rise clocks the IRQ counter,
+	// so quick toggles (e.g. 8x16 sprites from both pattern tables) are filtered out.
+	mmc3A12LowCycles = 9
+)
+
+func newMMC3(memory *memory) *mmc3 {
+	mapper := &mmc3{
+		baseMapper:       baseMapper{memory: memory},
+		prgRAMProtectReg: mmc3PrgRAMEnable,
+		isFourScreen:     memory.ntMirroringType == ntMirroringFourScreen}
+	mapper.updateBanks()
+
+	return mapper
+}
+
+func (mapper *mmc3) ReadPrgMemory(cpuAddress int) int {
+	if (cpuAddress&0xE000) == 0x6000 && (mapper.prgRAMProtectReg&mmc3PrgRAMEnable) == 0 {
+		return 0
+	}
+
+	return mapper.baseMapper.ReadPrgMemory(cpuAddress)
+}
+
+func (mapper *mmc3) WritePrgMemory(cpuAddress int, value int) {
+	if cpuAddress < 0x8000 {
+		if (cpuAddress & 0xE000) == 0x6000 {
+			if (mapper.prgRAMProtectReg&mmc3PrgRAMEnable) == 0 ||
+				(mapper.prgRAMProtectReg&mmc3PrgRAMWriteProtect) != 0 {
+				return
+			}
+		}
+		mapper.baseMapper.WritePrgMemory(cpuAddress, value)
+		return
+	}
+
+	isEven := (cpuAddress & 0x01) == 0
+
+	switch cpuAddress & 0xE000 {
+	case 0x8000:
+		if isEven {
+			mapper.bankSelectReg = value
+		} else {
+			mapper.bankRegs[mapper.bankSelectReg&mmc3BankSelectTarget] = value
+		}
+		mapper.updateBanks()
+
+	case 0xA000:
+		if isEven {
+			if !mapper.isFourScreen {
+				if (value & 0x01) == 0 {
+					mapper.memory.ntMirroringType = ntMirroringVertical
+				} else {
+					mapper.memory.ntMirroringType = ntMirroringHorizontal
+				}
+			}
+		} else {
+			mapper.prgRAMProtectReg = value
+		}
+
+	case 0xC000:
+		if isEven {
+			mapper.irqLatch = value
+		} else {
+			mapper.irqCounter = 0
+			mapper.irqReload = true
+		}
+
+	case 0xE000:
+		if isEven {
+			// Disable and acknowledge any pending IRQ
+			mapper.irqEnabled = false
+			mapper.setIRQ(false)
+		} else {
+			mapper.irqEnabled = true
+		}
+	}
+}
+
+func (mapper *mmc3) ObservePPUAddress(ppuAddress int, ppuCycle int) {
+	isA12High := (ppuAddress & 0x1000) != 0
+	if isA12High && !mapper.isA12High {
+		if (ppuCycle-mapper.a12LowCycle)&PPUCycleMask >= mmc3A12LowCycles {
+			mapper.clockIRQCounter()
+		}
+	} else if !isA12High && mapper.isA12High {
+		mapper.a12LowCycle = ppuCycle
+	}
+	mapper.isA12High = isA12High
+}
+
+func (mapper *mmc3) clockIRQCounter() {
+	if mapper.irqCounter == 0 || mapper.irqReload {
+		mapper.irqCounter = mapper.irqLatch
+		mapper.irqReload = false
+	} else {
+		mapper.irqCounter--
+	}
+
+	if mapper.irqCounter == 0 && mapper.irqEnabled {
+		mapper.setIRQ(true)
+	}
+}
+
+func (mapper *mmc3) updateBanks() {
+	// PRG ROM (8KB banks)
+	secondLastBank := mapper.prgROMBanksCount(8) - 2
+	if (mapper.bankSelectReg & mmc3BankSelectPrgMode) == 0 {
+		// R6 at $8000, second last bank fixed at $C000
+		mapper.mapPrgROM(0x8000, 8, mapper.bankRegs[6])
+		mapper.mapPrgROM(0xC000, 8, secondLastBank)
+	} else {
+		// Second last bank fixed at $8000, R6 at $C000
+		mapper.mapPrgROM(0x8000, 8, secondLastBank)
+		mapper.mapPrgROM(0xC000, 8, mapper.bankRegs[6])
+	}
+	mapper.mapPrgROM(0xA000, 8, mapper.bankRegs[7])
+	mapper.mapPrgROM(0xE000, 8, secondLastBank+1)
+
+	// CHR ROM/RAM (two 2KB banks and four 1KB banks, halves swapped by A12 inversion)
+	chrLowHalf := 0x0000
+	chrHighHalf := 0x1000
+	if (mapper.bankSelectReg & mmc3BankSelectChrInvert) != 0 {
+		chrLowHalf, chrHighHalf = chrHighHalf, chrLowHalf
+	}
+	mapper.mapChrMem(chrLowHalf, 2, mapper.bankRegs[0]>>1)
+	mapper.mapChrMem(chrLowHalf+0x0800, 2, mapper.bankRegs[1]>>1)
+	mapper.mapChrMem(chrHighHalf, 1, mapper.bankRegs[2])
+	mapper.mapChrMem(chrHighHalf+0x0400, 1, mapper.bankRegs[3])
+	mapper.mapChrMem(chrHighHalf+0x0800, 1, mapper.bankRegs[4])
+	mapper.mapChrMem(chrHighHalf+0x0C00, 1, mapper.bankRegs[5])
+}
@@ -0,0 +1,132 @@
+package cartridge
+
+import (
+	"bytes"
+	"testing"
+)
+
+type testIRQReceiver struct {
+	asserted bool
+}
+
+func (irqReceiver *testIRQReceiver) ReceiveIRQ(asserted bool) {
+	irqReceiver.asserted = asserted
+}
+
+func newTestMMC3(t *testing.T) (*Cartridge, *mmc3, *testIRQReceiver) {
+	rom := []byte{'N', 'E', 'S', 0x1A, 0x02, 0x01, 0x40, 0, 0, 0, 0, 0, 0, 0, 0, 0}
+	rom = append(rom, make([]byte, 32*1024+8*1024)...)
+	cartridge, err := New(bytes.NewReader(rom))
+	if err != nil {
+		t.Fatal(err)
+	}
+	irqReceiver := &testIRQReceiver{}
+	cartridge.SetIRQReceiver(irqReceiver)
+
+	return cartridge, cartridge.mapper.(*mmc3), irqReceiver
+}
+
+// renderScanline feeds pattern table fetches of a rendered scanline: 32 background tiles, 8 sprites
+// (8x16 sprites alternate pattern tables) and 2 tiles of the next line, 8 cycles per tile/sprite.
+func renderScanline(cartridge *Cartridge, line int, bgTable int, spriteTable int, isSprite16 bool) {
+	start := line * 341
+	for tile := 0; tile < 32; tile++ {
+		cartridge.ObservePPUAddress(bgTable|tile<<4, start+tile*8+5)
+		cartridge.ObservePPUAddress(bgTable|tile<<4|8, start+tile*8+7)
+	}
+	for sprite := 0; sprite < 8; sprite++ {
+		table := spriteTable
+		if isSprite16 && sprite%2 == 1 {
+			table ^= 0x1000
+		}
+		cartridge.ObservePPUAddress(table|0xFF0, start+257+sprite*8+4)
+		cartridge.ObservePPUAddress(table|0xFF8, start+257+sprite*8+6)
+	}
+	for tile := 0; tile < 2; tile++ {
+		cartridge.ObservePPUAddress(bgTable|tile<<4, start+321+tile*8+4)
+		cartridge.ObservePPUAddress(bgTable|tile<<4|8, start+321+tile*8+6)
+	}
+}
+
+func TestMMC3IRQCounter(t *testing.T) {
+	data := []struct {
+		name        string
+		bgTable     int
+		spriteTable int
+		isSprite16  bool
+	}{
+		{"BG $0000, sprites $1000", 0x0000, 0x1000, false},
+		{"BG $1000, sprites $0000", 0x1000, 0x0000, false},
+		{"8x16 sprites", 0x0000, 0x1000, true},
+	}
+
+	for _, tt := range data {
+		t.Run(tt.name, func(t *testing.T) {
+			cartridge, mapper, irqReceiver := newTestMMC3(t)
+			cartridge.WritePrgMemory(0xC000, 3) // Latch
+			cartridge.WritePrgMemory(0xC001, 0) // Reload
+			cartridge.WritePrgMemory(0xE001, 0) // Enable
+
+			// Reload to 3 on the first line, then one clock per line down to 0 and IRQ on the 4th
+			expected := []int{3, 2, 1, 0}
+			for line, counter := range expected {
+				renderScanline(cartridge, line, tt.bgTable, tt.spriteTable, tt.isSprite16)
+				if mapper.irqCounter != counter {
+					t.Fatalf("Line %v: counter %v, expected %v", line, mapper.irqCounter, counter)
+				}
+				if irqReceiver.asserted != (line == 3) {
+					t.Fatalf("Line %v: IRQ %v", line, irqReceiver.asserted)
+				}
+			}
+
+			// Line stays asserted until acknowledged by disabling
+			renderScanline(cartridge, 4, tt.bgTable, tt.spriteTable, tt.isSprite16)
+			if !irqReceiver.asserted || mapper.irqCounter != 3 {
+				t.Fatalf("IRQ %v, counter %v after reload", irqReceiver.asserted, mapper.irqCounter)
+			}
+			cartridge.WritePrgMemory(0xE000, 0)
+			if irqReceiver.asserted {
+				t.Fatalf("IRQ not acknowledged")
+			}
+
+			// Disabled counter keeps counting without IRQ
+			for line := 5; line < 8; line++ {
+				renderScanline(cartridge, line, tt.bgTable, tt.spriteTable, tt.isSprite16)
+			}
+			if irqReceiver.asserted || mapper.irqCounter != 0 {
+				t.Fatalf("IRQ %v, counter %v while disabled", irqReceiver.asserted, mapper.irqCounter)
+			}
+		})
+	}
+}
+
+func TestMMC3IRQReload(t *testing.T) {
+	cartridge, mapper, irqReceiver := newTestMMC3(t)
+	cartridge.WritePrgMemory(0xC000, 5)
+	cartridge.WritePrgMemory(0xC001, 0)
+	cartridge.WritePrgMemory(0xE001, 0)
+
+	renderScanline(cartridge, 0, 0x0000, 0x1000, false)
+	renderScanline(cartridge, 1, 0x0000, 0x1000, false)
+
+	// New latch is used on the next clock after reload
+	cartridge.WritePrgMemory(0xC000, 1)
+	cartridge.WritePrgMemory(0xC001, 0)
+	renderScanline(cartridge, 2, 0x0000, 0x1000, false)
+	if mapper.irqCounter != 1 || irqReceiver.asserted {
+		t.Fatalf("Counter %v, IRQ %v after reload", mapper.irqCounter, irqReceiver.asserted)
+	}
+	renderScanline(cartridge, 3, 0x0000, 0x1000, false)
+	if mapper.irqCounter != 0 || !irqReceiver.asserted {
+		t.Fatalf("Counter %v, IRQ %v", mapper.irqCounter, irqReceiver.asserted)
+	}
+
+	// Latch 0 asserts IRQ on every clock
+	cartridge.WritePrgMemory(0xE000, 0)
+	cartridge.WritePrgMemory(0xC000, 0)
+	cartridge.WritePrgMemory(0xE001, 0)
+	renderScanline(cartridge, 4, 0x0000, 0x1000, false)
+	if !irqReceiver.asserted {
+		t.Errorf("No IRQ with latch 0")
+	}
+}
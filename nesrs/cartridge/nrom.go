@@ -0,0 +1,14 @@
+package cartridge
+
+// NROM (mapper 0). No bank switching.
+type nrom struct {
+	baseMapper
+}
+
+func newNROM(memory *memory) *nrom {
+	mapper := &nrom{baseMapper{memory: memory}}
+	mapper.mapPrgROM(0x8000, 32, 0)
+	mapper.mapChrMem(0x0000, 8, 0)
+
+	return mapper
+}
@@ -70,7 +70,7 @@ func getNameTableIndex(ppuAddress int, ntMirroringType int) int {
 		case 0x0000:
 			return ntIndexA
 
-		case 0x0300:
+		case 0x0400:
 			return ntIndexB
 
 		case 0x0800:
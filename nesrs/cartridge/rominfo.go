@@ -0,0 +1,170 @@
+package cartridge
+
+// Console types (header byte 7, bits 0 & 1).
+const (
+	ConsoleNES = iota
+	ConsoleVsSystem
+	ConsolePlaychoice10
+	ConsoleExtended
+)
+
+// CPU/PPU timing regions (NES 2.0 header byte 12, bits 0 & 1).
+const (
+	TimingNTSC = iota
+	TimingPAL
+	TimingMultiRegion
+	TimingDendy
+)
+
+// RomInfo - ROM metadata described by an iNES or NES 2.0 header. Sizes are in bytes.
+type RomInfo struct {
+	IsNES20                bool // NES 2.0 header
+	MapperNumber           int  //
+	SubmapperNumber        int  // NES 2.0 only
+	PrgROMSize             int  //
+	ChrROMSize             int  //
+	PrgRAMSize             int  // Volatile PRG RAM
+	PrgNVRAMSize           int  // Battery backed PRG RAM (or EEPROM)
+	ChrRAMSize             int  // Volatile CHR RAM
+	ChrNVRAMSize           int  // Battery backed CHR RAM
+	HasTrainer             bool // 512 byte trainer at $7000-$71FF
+	HasBattery             bool // Battery or other non-volatile memory
+	IsVerticalMirroring    bool // Hardwired vertical mirroring (horizontal otherwise)
+	IsFourScreen           bool // Hardwired four screen VRAM
+	ConsoleType            int  // Console*
+	TimingRegion           int  // Timing*
+	VsPPUType              int  // Vs. System only
+	VsHardwareType         int  // Vs. System only
+	ExtendedConsoleType    int  // Extended console only
+	MiscROMsCount          int  // NES 2.0 only
+	DefaultExpansionDevice int  // NES 2.0 only
+}
+
+// Upper limit for ROM sizes (sanity check against corrupted headers).
+const maxROMSize = 64 * 1024 * 1024
+
+// NewRomInfo parses the 16 byte iNES/NES 2.0 header.
+func NewRomInfo(headers []byte) (*RomInfo, error) {
+	if len(headers) < 16 {
+		return nil, &TruncatedError{"header"}
+	}
+	if headers[0] != 'N' || headers[1] != 'E' || headers[2] != 'S' || headers[3] != 0x1A {
+		return nil, ErrInvalidMagic
+	}
+
+	info := RomInfo{}
+
+	info.HasTrainer = (headers[6] & 0x04) != 0
+	info.HasBattery = (headers[6] & 0x02) != 0
+	info.IsVerticalMirroring = (headers[6] & 0x01) != 0
+	info.IsFourScreen = (headers[6] & 0x08) != 0
+	info.ConsoleType = int(headers[7] & 0x03)
+	info.MapperNumber = int((headers[6] >> 4) & 0x0F)
+
+	if (headers[7] & 0x0C) == 0x08 {
+		info.parseNES20(headers)
+	} else {
+		info.parseINES(headers)
+	}
+
+	if info.PrgROMSize <= 0 || info.PrgROMSize > maxROMSize ||
+		info.ChrROMSize < 0 || info.ChrROMSize > maxROMSize ||
+		info.PrgROMSize%1024 != 0 || info.ChrROMSize%1024 != 0 {
+		return nil, ErrInvalidSize
+	}
+
+	return &info, nil
+}
+
+func (info *RomInfo) parseINES(headers []byte) {
+	// Old dumps have garbage (e.g. "DiskDude!") at bytes 7 and 11-15. Mapper upper nibble can be trusted
+	// (and PRG RAM size) only when those are zero.
+	isMapperNumberUpperNibbleSupported := (headers[7] & 0x0C) == 0
+	for i := 11; i < len(headers); i++ {
+		if headers[i] != 0 {
+			isMapperNumberUpperNibbleSupported = false
+			break
+		}
+	}
+	if isMapperNumberUpperNibbleSupported {
+		info.MapperNumber |= int(headers[7] & 0xF0)
+	} else {
+		info.ConsoleType = ConsoleNES
+	}
+
+	info.PrgROMSize = int(headers[4]) * 16 * 1024
+	info.ChrROMSize = int(headers[5]) * 8 * 1024
+
+	// PRG RAM size in 8KB units. 0 infers 8KB for compatibility.
+	prgRAMSize := int(headers[8])
+	if prgRAMSize == 0 || !isMapperNumberUpperNibbleSupported {
+		prgRAMSize = 1
+	}
+	prgRAMSize *= 8 * 1024
+	if info.HasBattery {
+		info.PrgNVRAMSize = prgRAMSize
+	} else {
+		info.PrgRAMSize = prgRAMSize
+	}
+
+	if info.ChrROMSize == 0 {
+		info.ChrRAMSize = 8 * 1024
+	}
+
+	if isMapperNumberUpperNibbleSupported && (headers[9]&0x01) != 0 {
+		info.TimingRegion = TimingPAL
+	}
+}
+
+func (info *RomInfo) parseNES20(headers []byte) {
+	info.IsNES20 = true
+
+	info.MapperNumber |= int(headers[7]&0xF0) | (int(headers[8]&0x0F) << 8)
+	info.SubmapperNumber = int((headers[8] >> 4) & 0x0F)
+
+	info.PrgROMSize = nes20ROMSize(headers[4], headers[9]&0x0F, 16*1024)
+	info.ChrROMSize = nes20ROMSize(headers[5], (headers[9]>>4)&0x0F, 8*1024)
+
+	info.PrgRAMSize = nes20RAMSize(headers[10] & 0x0F)
+	info.PrgNVRAMSize = nes20RAMSize((headers[10] >> 4) & 0x0F)
+	info.ChrRAMSize = nes20RAMSize(headers[11] & 0x0F)
+	info.ChrNVRAMSize = nes20RAMSize((headers[11] >> 4) & 0x0F)
+
+	info.TimingRegion = int(headers[12] & 0x03)
+
+	switch info.ConsoleType {
+	case ConsoleVsSystem:
+		info.VsPPUType = int(headers[13] & 0x0F)
+		info.VsHardwareType = int((headers[13] >> 4) & 0x0F)
+	case ConsoleExtended:
+		info.ExtendedConsoleType = int(headers[13] & 0x0F)
+	}
+
+	info.MiscROMsCount = int(headers[14] & 0x03)
+	info.DefaultExpansionDevice = int(headers[15] & 0x3F)
+}
+
+// nes20ROMSize decodes PRG/CHR ROM size. When the MSB nibble is $F the LSB byte is in exponent-multiplier
+// notation (EEEE EEMM -> 2^E * (MM*2 + 1)), otherwise the 12 bit value counts units of the given size.
+// Returns -1 when the size is too big to compute.
+func nes20ROMSize(lsb byte, msb byte, unitSize int) int {
+	if msb == 0x0F {
+		exponent := uint((lsb >> 2) & 0x3F)
+		multiplier := int(lsb&0x03)*2 + 1
+		if (1 << exponent) > maxROMSize {
+			return -1
+		}
+		return (1 << exponent) * multiplier
+	}
+
+	return (int(msb)<<8 | int(lsb)) * unitSize
+}
+
+// nes20RAMSize decodes RAM shift count (64 << shift, 0 means no RAM).
+func nes20RAMSize(shift byte) int {
+	if shift == 0 {
+		return 0
+	}
+
+	return 64 << uint(shift)
+}
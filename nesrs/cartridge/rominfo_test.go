@@ -0,0 +1,66 @@
+package cartridge
+
+import (
+	"testing"
+)
+
+func TestNewRomInfo(t *testing.T) {
+
+	data := []struct {
+		name    string
+		headers []byte
+		want    RomInfo
+	}{
+		{
+			"iNES",
+			[]byte{'N', 'E', 'S', 0x1A, 0x08, 0x10, 0x13, 0x40, 0x00, 0x00, 0, 0, 0, 0, 0, 0},
+			RomInfo{MapperNumber: 0x41, PrgROMSize: 128 * 1024, ChrROMSize: 128 * 1024,
+				PrgNVRAMSize: 8 * 1024, HasBattery: true, IsVerticalMirroring: true},
+		},
+		{
+			"iNES with garbage",
+			[]byte{'N', 'E', 'S', 0x1A, 0x02, 0x00, 0x10, 'D', 'i', 's', 'k', 'D', 'u', 'd', 'e', '!'},
+			RomInfo{MapperNumber: 0x01, PrgROMSize: 32 * 1024, PrgRAMSize: 8 * 1024, ChrRAMSize: 8 * 1024},
+		},
+		{
+			"NES 2.0",
+			[]byte{'N', 'E', 'S', 0x1A, 0x20, 0x28, 0x4A, 0x19, 0x21, 0xF0, 0x70, 0x07, 0x01, 0x00, 0x00, 0x01},
+			RomInfo{IsNES20: true, MapperNumber: 0x114, SubmapperNumber: 2, PrgROMSize: 512 * 1024,
+				ChrROMSize: 1024, PrgNVRAMSize: 8 * 1024, ChrRAMSize: 8 * 1024, HasTrainer: false,
+				HasBattery: true, IsFourScreen: true, ConsoleType: ConsoleVsSystem,
+				TimingRegion: TimingPAL, DefaultExpansionDevice: 1},
+		},
+	}
+
+	for _, tt := range data {
+		t.Run(tt.name, func(t *testing.T) {
+			info, err := NewRomInfo(tt.headers)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := *info; got != tt.want {
+				t.Errorf("\nWrong %+v\nRight %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewRomInfoErrors(t *testing.T) {
+	data := []struct {
+		name    string
+		headers []byte
+	}{
+		{"no PRG", []byte{'N', 'E', 'S', 0x1A, 0x00, 0x01, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0}},
+		{"NES 2.0 PRG exponent", []byte{'N', 'E', 'S', 0x1A, 0xFC, 0x01, 0x00, 0x08, 0x00, 0x0F, 0, 0, 0, 0, 0, 0}},
+		{"NES 2.0 CHR exponent", []byte{'N', 'E', 'S', 0x1A, 0x01, 0xFD, 0x00, 0x08, 0x00, 0xF0, 0, 0, 0, 0, 0, 0}},
+		{"NES 2.0 too big", []byte{'N', 'E', 'S', 0x1A, 0x6B, 0x01, 0x00, 0x08, 0x00, 0x0F, 0, 0, 0, 0, 0, 0}},
+	}
+
+	for _, tt := range data {
+		t.Run(tt.name, func(t *testing.T) {
+			if info, err := NewRomInfo(tt.headers); err != ErrInvalidSize {
+				t.Errorf("Expected ErrInvalidSize, got %v %+v", err, info)
+			}
+		})
+	}
+}
@@ -0,0 +1,106 @@
+package cartridge
+
+import (
+	"github.com/alpetkov/nesrs_go/nesrs/state"
+)
+
+// SaveState of cartridge RAM, mirroring and bank mapping.
+func (mapper *baseMapper) SaveState(writer *state.Writer) {
+	writer.WriteArray(mapper.prgROMMap[:])
+	writer.WriteArray(mapper.chrMemMap[:])
+	writer.WriteInt(mapper.memory.ntMirroringType)
+	writer.WriteArray(mapper.memory.prgRAM)
+	if mapper.memory.isChrMemRAM {
+		for _, bank := range mapper.memory.chrMem {
+			writer.WriteArray(bank)
+		}
+	}
+	for _, nt := range mapper.memory.ntExtraVRAM {
+		writer.WriteArray(nt)
+	}
+}
+
+// LoadState of cartridge RAM, mirroring and bank mapping.
+func (mapper *baseMapper) LoadState(reader *state.Reader) {
+	reader.ReadArray(mapper.prgROMMap[:])
+	reader.ReadArray(mapper.chrMemMap[:])
+	mapper.memory.ntMirroringType = reader.ReadIntRange(0, ntMirroringFourScreen+1)
+	reader.ReadArray(mapper.memory.prgRAM)
+	if mapper.memory.isChrMemRAM {
+		for _, bank := range mapper.memory.chrMem {
+			reader.ReadArray(bank)
+		}
+	}
+	for _, nt := range mapper.memory.ntExtraVRAM {
+		reader.ReadArray(nt)
+	}
+
+	// Four screen mirroring needs the extra VRAM on the cartridge
+	if mapper.memory.ntMirroringType == ntMirroringFourScreen && mapper.memory.ntExtraVRAM == nil {
+		reader.SetErr(state.ErrInvalidValue)
+	}
+
+	// Bank numbers index ROM/RAM slices
+	for _, bank := range mapper.prgROMMap {
+		if bank < 0 || bank >= len(mapper.memory.prgROM) {
+			reader.SetErr(state.ErrInvalidValue)
+		}
+	}
+	for _, bank := range mapper.chrMemMap {
+		if bank < 0 || bank >= len(mapper.memory.chrMem) {
+			reader.SetErr(state.ErrInvalidValue)
+		}
+	}
+}
+
+// SaveState .
+func (mapper *mmc1) SaveState(writer *state.Writer) {
+	mapper.baseMapper.SaveState(writer)
+	writer.WriteInt(mapper.shiftRegister)
+	writer.WriteInt(mapper.shiftCount)
+	writer.WriteInt(mapper.controlReg)
+	writer.WriteInt(mapper.chrBank0Reg)
+	writer.WriteInt(mapper.chrBank1Reg)
+	writer.WriteInt(mapper.prgBankReg)
+}
+
+// LoadState .
+func (mapper *mmc1) LoadState(reader *state.Reader) {
+	mapper.baseMapper.LoadState(reader)
+	mapper.shiftRegister = reader.ReadIntRange(0, 0x20)
+	mapper.shiftCount = reader.ReadIntRange(0, 5)
+	mapper.controlReg = reader.ReadIntRange(0, 0x20)
+	mapper.chrBank0Reg = reader.ReadIntRange(0, 0x20)
+	mapper.chrBank1Reg = reader.ReadIntRange(0, 0x20)
+	mapper.prgBankReg = reader.ReadIntRange(0, 0x20)
+}
+
+// SaveState .
+func (mapper *mmc3) SaveState(writer *state.Writer) {
+	mapper.baseMapper.SaveState(writer)
+	writer.WriteInt(mapper.bankSelectReg)
+	writer.WriteArray(mapper.bankRegs[:])
+	writer.WriteInt(mapper.prgRAMProtectReg)
+	writer.WriteInt(mapper.irqLatch)
+	writer.WriteInt(mapper.irqCounter)
+	writer.WriteBool(mapper.irqReload)
+	writer.WriteBool(mapper.irqEnabled)
+	writer.WriteBool(mapper.isA12High)
+	writer.WriteInt(mapper.a12LowCycle)
+}
+
+// LoadState .
+func (mapper *mmc3) LoadState(reader *state.Reader) {
+	mapper.baseMapper.LoadState(reader)
+	mapper.bankSelectReg = reader.ReadIntRange(0, 0x100)
+	for i := range mapper.bankRegs {
+		mapper.bankRegs[i] = reader.ReadIntRange(0, 0x100)
+	}
+	mapper.prgRAMProtectReg = reader.ReadIntRange(0, 0x100)
+	mapper.irqLatch = reader.ReadIntRange(0, 0x100)
+	mapper.irqCounter = reader.ReadIntRange(0, 0x100)
+	mapper.irqReload = reader.ReadBool()
+	mapper.irqEnabled = reader.ReadBool()
+	mapper.isA12High = reader.ReadBool()
+	mapper.a12LowCycle = reader.ReadIntRange(0, PPUCycleMask+1)
+}
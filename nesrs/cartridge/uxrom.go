@@ -0,0 +1,24 @@
+package cartridge
+
+// UxROM (mapper 2). Switchable 16KB bank at $8000, last bank fixed at $C000.
+type uxrom struct {
+	baseMapper
+}
+
+func newUxROM(memory *memory) *uxrom {
+	mapper := &uxrom{baseMapper{memory: memory}}
+	mapper.mapPrgROM(0x8000, 16, 0)
+	mapper.mapPrgROM(0xC000, 16, mapper.prgROMBanksCount(16)-1)
+	mapper.mapChrMem(0x0000, 8, 0)
+
+	return mapper
+}
+
+func (mapper *uxrom) WritePrgMemory(cpuAddress int, value int) {
+	if cpuAddress < 0x8000 {
+		mapper.baseMapper.WritePrgMemory(cpuAddress, value)
+		return
+	}
+
+	mapper.mapPrgROM(0x8000, 16, value)
+}
@@ -0,0 +1,104 @@
+// Command nesrs-term plays a ROM in a terminal (e.g. over SSH): video is drawn with half-block
+// characters in 24-bit color and player 1 is controlled from the keyboard. There is no audio.
+//
+// Usage:
+//
+//	nesrs-term [flags] rom.nes
+//
+// Keys: arrows or WASD, X/K - A, Z/J - B, Enter - Start, Space/Tab - Select, Q/Ctrl-C - quit.
+package main
+
+import (
+	"bytes"
+	"context"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"time"
+
+	"github.com/alpetkov/nesrs_go/nesrs"
+	"github.com/alpetkov/nesrs_go/nesrs/terminal"
+)
+
+func main() {
+	scale := flag.Int("scale", 2, "downscale factor (1 needs 256x120 characters, 2 - 128x60, 4 - 64x30)")
+	speed := flag.Float64("speed", 1, "emulation speed")
+	hold := flag.Duration("hold", terminal.DefaultHold, "how long a key press holds the button")
+	sramPath := flag.String("sram", "", "battery-backed RAM file, loaded if it exists and saved at the end")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "Usage: %s [flags] rom.nes\n", os.Args[0])
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	if flag.NArg() != 1 {
+		flag.Usage()
+		os.Exit(1)
+	}
+
+	if err := play(flag.Arg(0), *scale, *speed, *hold, *sramPath); err != nil {
+		fmt.Fprintf(os.Stderr, "nesrs-term: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func play(romPath string, scale int, speed float64, hold time.Duration, sramPath string) error {
+	rom, err := ioutil.ReadFile(romPath)
+	if err != nil {
+		return err
+	}
+
+	restore, err := terminal.MakeRaw(int(os.Stdin.Fd()))
+	if err != nil {
+		return err
+	}
+	defer restore()
+
+	video := terminal.NewVideoReceiver(os.Stdout, scale)
+	defer video.Close()
+	keyboard := terminal.NewKeyboard(os.Stdin, nil)
+	keyboard.SetHold(hold)
+
+	nes, err := nesrs.New(rom, video, nil, keyboard)
+	if err != nil {
+		return err
+	}
+	if err := nes.SetSpeed(speed); err != nil {
+		return err
+	}
+
+	if sramPath != "" && nes.IsBatteryBacked() {
+		if sram, err := ioutil.ReadFile(sramPath); err == nil {
+			if err := nes.LoadSRAM(bytes.NewReader(sram)); err != nil {
+				return err
+			}
+		} else if !os.IsNotExist(err) {
+			return err
+		}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		<-keyboard.Quit()
+		cancel()
+	}()
+
+	nes.Start()
+	nes.RunContext(ctx)
+	nes.Stop()
+
+	if sramPath != "" && nes.IsBatteryBacked() {
+		file, err := os.Create(sramPath)
+		if err != nil {
+			return err
+		}
+		if err := nes.SaveSRAM(file); err != nil {
+			file.Close()
+			return err
+		}
+		return file.Close()
+	}
+
+	return nil
+}
@@ -0,0 +1,119 @@
+package main
+
+import (
+	"os"
+
+	"github.com/alpetkov/nesrs_go/nesrs/apu"
+	"github.com/alpetkov/nesrs_go/nesrs/ppu"
+)
+
+// capture streams video and audio to files while running.
+type capture struct {
+	files []*os.File
+	y4m   *ppu.Y4MVideoReceiver
+	raw   *ppu.RawVideoReceiver
+	wav   *apu.WAVStreamAudioReceiver
+}
+
+// openCapture creates the capture files requested by the options.
+func openCapture(options options) (*capture, error) {
+	capture := &capture{}
+
+	if options.y4mPath != "" {
+		file, err := capture.create(options.y4mPath)
+		if err != nil {
+			return nil, err
+		}
+		capture.y4m = ppu.NewY4MVideoReceiver(file)
+	}
+
+	if options.rgbPath != "" {
+		timecodesPath := options.timecodesPath
+		if timecodesPath == "" {
+			timecodesPath = options.rgbPath + ".timecodes.txt"
+		}
+
+		file, err := capture.create(options.rgbPath)
+		if err != nil {
+			return nil, err
+		}
+		timecodes, err := capture.create(timecodesPath)
+		if err != nil {
+			return nil, err
+		}
+		capture.raw = ppu.NewRawVideoReceiver(file, timecodes)
+	}
+
+	if options.wavPath != "" {
+		file, err := capture.create(options.wavPath)
+		if err != nil {
+			return nil, err
+		}
+		capture.wav = apu.NewWAVStreamAudioReceiver(file, options.sampleRate)
+	}
+
+	return capture, nil
+}
+
+// videoReceivers - active video streams.
+func (capture *capture) videoReceivers() videoReceivers {
+	var receivers videoReceivers
+	if capture.y4m != nil {
+		receivers = append(receivers, capture.y4m)
+	}
+	if capture.raw != nil {
+		receivers = append(receivers, capture.raw)
+	}
+
+	return receivers
+}
+
+// audioReceiver - active audio stream or nil.
+func (capture *capture) audioReceiver() apu.AudioReceiver {
+	if capture.wav == nil {
+		return nil
+	}
+
+	return capture.wav
+}
+
+// close finishes the streams and closes the files. Returns the first error. Closing again
+// does nothing.
+func (capture *capture) close() error {
+	var errs []error
+	if capture.y4m != nil {
+		errs = append(errs, capture.y4m.Err())
+	}
+	if capture.raw != nil {
+		errs = append(errs, capture.raw.Err())
+	}
+	if capture.wav != nil {
+		errs = append(errs, capture.wav.Close())
+	}
+	for _, file := range capture.files {
+		errs = append(errs, file.Close())
+	}
+	capture.y4m = nil
+	capture.raw = nil
+	capture.wav = nil
+	capture.files = nil
+
+	for _, err := range errs {
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (capture *capture) create(path string) (*os.File, error) {
+	file, err := os.Create(path)
+	if err != nil {
+		capture.close()
+		return nil, err
+	}
+	capture.files = append(capture.files, file)
+
+	return file, nil
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"bytes"
+	"io/ioutil"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/alpetkov/nesrs_go/nesrs/apu"
+	"github.com/alpetkov/nesrs_go/nesrs/ppu"
+)
+
+// testROM - NROM program with rendering and NMI enabled, looping forever.
+func testROM() []byte {
+	rom := []byte{'N', 'E', 'S', 0x1A, 1, 1, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0}
+	prg := make([]byte, 0x4000)
+	// $8000: LDA #$80, STA $2000, LDA #$1E, STA $2001, loop: JMP loop
+	copy(prg, []byte{0xA9, 0x80, 0x8D, 0x00, 0x20, 0xA9, 0x1E, 0x8D, 0x01, 0x20, 0x4C, 0x0A, 0x80})
+	// NMI: RTI
+	prg[0x40] = 0x40
+	prg[0x3FFA], prg[0x3FFB] = 0x40, 0x80
+	prg[0x3FFC], prg[0x3FFD] = 0x00, 0x80
+
+	rom = append(rom, prg...)
+	return append(rom, make([]byte, 0x2000)...)
+}
+
+func TestCapture(t *testing.T) {
+	dir, err := ioutil.TempDir("", "nesrs")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	romPath := filepath.Join(dir, "test.nes")
+	if err := ioutil.WriteFile(romPath, testROM(), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	options := options{
+		romPath:    romPath,
+		frames:     60,
+		y4mPath:    filepath.Join(dir, "out.y4m"),
+		wavPath:    filepath.Join(dir, "out.wav"),
+		sampleRate: 44100}
+	if _, err := run(options); err != nil {
+		t.Fatal(err)
+	}
+
+	y4m, _ := ioutil.ReadFile(options.y4mPath)
+	wav, _ := ioutil.ReadFile(options.wavPath)
+	frames := (len(y4m) - len("YUV4MPEG2 W256 H240 F39375000:655171 Ip A8:7 C444\n")) / (len("FRAME\n") + 3*256*240)
+	samples := (len(wav) - 44) / 2
+	if frames != 60 {
+		t.Errorf("Wrong frames count %v", frames)
+	}
+
+	// Audio ends with the last frame: 60 frames, the first one a scanline short
+	expected := ppu.FrameTime(60).Seconds() * (1 - 1/(60*262.0)) * 44100
+	if math.Abs(float64(samples)-expected) > 2 {
+		t.Errorf("Wrong samples count %v, expected %.1f", samples, expected)
+	}
+}
+
+func TestCaptureClose(t *testing.T) {
+	dir, err := ioutil.TempDir("", "nesrs")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	capture, err := openCapture(options{wavPath: filepath.Join(dir, "out.wav")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	capture.audioReceiver().ReceiveSamples([]int16{1, 2, 3})
+
+	if err := capture.close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := capture.close(); err != nil {
+		t.Errorf("Second close failed: %v", err)
+	}
+
+	var expected bytes.Buffer
+	apu.WriteWAV(&expected, 44100, []int16{1, 2, 3})
+	if wav, _ := ioutil.ReadFile(filepath.Join(dir, "out.wav")); !bytes.Equal(wav, expected.Bytes()) {
+		t.Errorf("Wrong WAV file (%v bytes)", len(wav))
+	}
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/alpetkov/nesrs_go/nesrs"
+)
+
+// condition on CPU memory, e.g. "$6000!=$80" or "0x00FF==3".
+type condition struct {
+	address    int
+	isNotEqual bool
+	value      int
+}
+
+func parseCondition(text string) (*condition, error) {
+	cond := condition{}
+
+	operator := "=="
+	i := strings.Index(text, operator)
+	if j := strings.Index(text, "!="); j >= 0 {
+		operator, i = "!=", j
+	}
+	if i < 0 {
+		return nil, fmt.Errorf("invalid condition %q (expected ADDRESS==VALUE or ADDRESS!=VALUE)", text)
+	}
+	cond.isNotEqual = operator == "!="
+
+	var err error
+	if cond.address, err = parseNumber(text[:i]); err != nil || cond.address > 0xFFFF {
+		return nil, fmt.Errorf("invalid address in condition %q", text)
+	}
+	if cond.value, err = parseNumber(text[i+len(operator):]); err != nil || cond.value > 0xFF {
+		return nil, fmt.Errorf("invalid value in condition %q", text)
+	}
+
+	return &cond, nil
+}
+
+// parseNumber - decimal, or hexadecimal with '$' or '0x' prefix.
+func parseNumber(text string) (int, error) {
+	text = strings.TrimSpace(text)
+
+	base := 10
+	if strings.HasPrefix(text, "$") {
+		text, base = text[1:], 16
+	} else if strings.HasPrefix(strings.ToLower(text), "0x") {
+		text, base = text[2:], 16
+	}
+
+	value, err := strconv.ParseUint(text, base, 16)
+	return int(value), err
+}
+
+func (cond *condition) isMet(nes *nesrs.NES) bool {
+	isEqual := nes.Peek(cond.address) == cond.value
+	return isEqual != cond.isNotEqual
+}
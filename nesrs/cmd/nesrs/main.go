@@ -0,0 +1,327 @@
+// Command nesrs runs a ROM headless: for a number of frames or until a memory condition is met,
+// with scripted input, and dumps a screenshot, GIF, video and audio streams, SRAM and the final
+// save state.
+//
+// Usage:
+//
+//	nesrs [flags] rom.nes
+//
+// Exit status is 0 on success, 1 on error and 2 when -until condition isn't met within -frames.
+package main
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/alpetkov/nesrs_go/nesrs"
+	"github.com/alpetkov/nesrs_go/nesrs/movie"
+	"github.com/alpetkov/nesrs_go/nesrs/ppu"
+)
+
+const (
+	exitError   = 1
+	exitTimeout = 2
+)
+
+// videoReceivers forwards frames to all receivers.
+type videoReceivers []ppu.VideoReceiver
+
+// ReceiveFrame .
+func (videoReceivers videoReceivers) ReceiveFrame(frame []int) {
+	for _, videoReceiver := range videoReceivers {
+		videoReceiver.ReceiveFrame(frame)
+	}
+}
+
+// ReceiveNumberedFrame .
+func (videoReceivers videoReceivers) ReceiveNumberedFrame(frame []int, number int) {
+	for _, videoReceiver := range videoReceivers {
+		if numberedVideoReceiver, ok := videoReceiver.(ppu.NumberedVideoReceiver); ok {
+			numberedVideoReceiver.ReceiveNumberedFrame(frame, number)
+		} else {
+			videoReceiver.ReceiveFrame(frame)
+		}
+	}
+}
+
+// lastFrame keeps the last frame rendered.
+type lastFrame struct {
+	frame []int
+}
+
+// ReceiveFrame .
+func (lastFrame *lastFrame) ReceiveFrame(frame []int) {
+	lastFrame.frame = append(lastFrame.frame[:0], frame...)
+}
+
+func main() {
+	frames := flag.Int("frames", 600, "number of frames to run (limit when -until is used)")
+	until := flag.String("until", "", "stop when memory condition is met, e.g. '$6000!=$80'")
+	inputPath := flag.String("input", "", "input script (lines of '<frame> <buttons> [<buttons>]')")
+	moviePath := flag.String("movie", "", "fm2 movie to play")
+	screenshotPath := flag.String("screenshot", "", "write last frame (.ppm or .png)")
+	gifPath := flag.String("gif", "", "record frames as animated GIF")
+	gifFirst := flag.Int("gif-first", 0, "first frame recorded in GIF")
+	gifFrames := flag.Int("gif-frames", 0, "number of frames recorded in GIF (0 - all)")
+	y4mPath := flag.String("y4m", "", "stream video as YUV4MPEG2 (.y4m)")
+	rgbPath := flag.String("rgb", "", "stream video as raw RGB24 frames")
+	timecodesPath := flag.String("timecodes", "", "timestamps of -rgb frames (default <rgb>.timecodes.txt)")
+	wavPath := flag.String("wav", "", "stream audio (.wav)")
+	sampleRate := flag.Int("rate", 44100, "audio sample rate")
+	sramPath := flag.String("sram", "", "battery-backed RAM file, loaded if it exists and saved at the end")
+	loadStatePath := flag.String("load-state", "", "start from save state")
+	statePath := flag.String("state", "", "write final save state")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "Usage: %s [flags] rom.nes\n", os.Args[0])
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	if flag.NArg() != 1 {
+		flag.Usage()
+		os.Exit(exitError)
+	}
+
+	options := options{
+		romPath:        flag.Arg(0),
+		frames:         *frames,
+		until:          *until,
+		inputPath:      *inputPath,
+		moviePath:      *moviePath,
+		screenshotPath: *screenshotPath,
+		gifPath:        *gifPath,
+		gifFirst:       *gifFirst,
+		gifFrames:      *gifFrames,
+		y4mPath:        *y4mPath,
+		rgbPath:        *rgbPath,
+		timecodesPath:  *timecodesPath,
+		wavPath:        *wavPath,
+		sampleRate:     *sampleRate,
+		sramPath:       *sramPath,
+		loadStatePath:  *loadStatePath,
+		statePath:      *statePath}
+
+	isConditionMet, err := run(options)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "nesrs: %v\n", err)
+		os.Exit(exitError)
+	}
+	if !isConditionMet {
+		fmt.Fprintf(os.Stderr, "nesrs: condition %s not met in %d frames\n", options.until, options.frames)
+		os.Exit(exitTimeout)
+	}
+}
+
+type options struct {
+	romPath        string
+	frames         int
+	until          string
+	inputPath      string
+	moviePath      string
+	screenshotPath string
+	gifPath        string
+	gifFirst       int
+	gifFrames      int
+	y4mPath        string
+	rgbPath        string
+	timecodesPath  string
+	wavPath        string
+	sampleRate     int
+	sramPath       string
+	loadStatePath  string
+	statePath      string
+}
+
+// run the ROM. Returns false when the condition isn't met.
+func run(options options) (bool, error) {
+	if err := checkOptions(options); err != nil {
+		return false, err
+	}
+
+	rom, err := ioutil.ReadFile(options.romPath)
+	if err != nil {
+		return false, err
+	}
+
+	var stop *condition
+	if options.until != "" {
+		if stop, err = parseCondition(options.until); err != nil {
+			return false, err
+		}
+	}
+
+	script := &inputScript{}
+	if options.inputPath != "" {
+		file, err := os.Open(options.inputPath)
+		if err != nil {
+			return false, err
+		}
+		script, err = readInputScript(file)
+		file.Close()
+		if err != nil {
+			return false, err
+		}
+	}
+
+	// Receivers
+	last := &lastFrame{}
+	gif := &ppu.GIFVideoReceiver{First: options.gifFirst, Count: options.gifFrames}
+	video := videoReceivers{last}
+	if options.gifPath != "" {
+		video = append(video, gif)
+	}
+	capture, err := openCapture(options)
+	if err != nil {
+		return false, err
+	}
+	defer capture.close()
+	video = append(video, capture.videoReceivers()...)
+
+	nes, err := nesrs.New(rom, video, capture.audioReceiver(), script)
+	if err != nil {
+		return false, err
+	}
+
+	if options.sramPath != "" && nes.IsBatteryBacked() {
+		if sram, err := ioutil.ReadFile(options.sramPath); err == nil {
+			if err := nes.LoadSRAM(bytes.NewReader(sram)); err != nil {
+				return false, err
+			}
+		} else if !os.IsNotExist(err) {
+			return false, err
+		}
+	}
+
+	// Start
+	script.advance(0)
+	if options.moviePath != "" {
+		file, err := os.Open(options.moviePath)
+		if err != nil {
+			return false, err
+		}
+		recorded, err := movie.Read(file)
+		file.Close()
+		if err != nil {
+			return false, err
+		}
+		if err := nes.PlayMovie(recorded); err != nil {
+			return false, err
+		}
+	} else {
+		nes.Start()
+	}
+	if options.loadStatePath != "" {
+		state, err := ioutil.ReadFile(options.loadStatePath)
+		if err != nil {
+			return false, err
+		}
+		if err := nes.LoadState(bytes.NewReader(state)); err != nil {
+			return false, err
+		}
+	}
+
+	// Run
+	isConditionMet := stop == nil
+	for frame := 0; frame < options.frames; frame++ {
+		if stop != nil && stop.isMet(nes) {
+			isConditionMet = true
+			fmt.Printf("Condition %s met at frame %d\n", options.until, nes.Frame())
+			break
+		}
+
+		if script.advance(nes.Frame() + 1) {
+			nes.Reset()
+		}
+		nes.RunFrame()
+	}
+	if !isConditionMet && stop.isMet(nes) {
+		isConditionMet = true
+	}
+	// Send the rest of the audio so it ends with the last frame
+	nes.Stop()
+
+	// Outputs
+	if options.screenshotPath != "" && last.frame != nil {
+		if err := writeScreenshot(options.screenshotPath, last.frame); err != nil {
+			return false, err
+		}
+	}
+	if options.gifPath != "" {
+		if err := writeFile(options.gifPath, gif.Write); err != nil {
+			return false, err
+		}
+	}
+	if err := capture.close(); err != nil {
+		return false, err
+	}
+	if options.sramPath != "" && nes.IsBatteryBacked() {
+		if err := writeFile(options.sramPath, nes.SaveSRAM); err != nil {
+			return false, err
+		}
+	}
+	if options.statePath != "" {
+		if err := writeFile(options.statePath, nes.SaveState); err != nil {
+			return false, err
+		}
+	}
+
+	return isConditionMet, nil
+}
+
+// checkOptions of the outputs written after the run, so mistakes don't waste it.
+func checkOptions(options options) error {
+	if options.screenshotPath != "" {
+		switch strings.ToLower(filepath.Ext(options.screenshotPath)) {
+		case ".png", ".ppm":
+		default:
+			return fmt.Errorf("unknown screenshot format %q", options.screenshotPath)
+		}
+	}
+	if options.gifFirst < 0 || options.gifFrames < 0 {
+		return errors.New("-gif-first and -gif-frames can't be negative")
+	}
+	if options.gifPath == "" && (options.gifFirst != 0 || options.gifFrames != 0) {
+		return errors.New("-gif-first and -gif-frames require -gif")
+	}
+
+	return nil
+}
+
+func writeScreenshot(path string, frame []int) error {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".png":
+		pngVideoReceiver := new(ppu.PNGVideoReceiver)
+		pngVideoReceiver.ReceiveFrame(frame)
+		return writeFile(path, pngVideoReceiver.Write)
+	case ".ppm":
+		ppmVideoReceiver := new(ppu.PPMVideoReceiver)
+		ppmVideoReceiver.ReceiveFrame(frame)
+		return writeFile(path, func(out io.Writer) error {
+			ppmVideoReceiver.Write(out)
+			return nil
+		})
+	}
+
+	return fmt.Errorf("unknown screenshot format %q", path)
+}
+
+func writeFile(path string, write func(out io.Writer) error) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+
+	if err := write(file); err != nil {
+		file.Close()
+		return err
+	}
+
+	return file.Close()
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestCheckOptions(t *testing.T) {
+	dir, err := ioutil.TempDir("", "nesrs")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	romPath := filepath.Join(dir, "test.nes")
+	if err := ioutil.WriteFile(romPath, testROM(), 0644); err != nil {
+		t.Fatal(err)
+	}
+	y4mPath := filepath.Join(dir, "out.y4m")
+
+	data := []struct {
+		options options
+		isValid bool
+	}{
+		{options{screenshotPath: "out.PNG"}, true},
+		{options{screenshotPath: "out.ppm"}, true},
+		{options{screenshotPath: "out.bmp"}, false},
+		{options{screenshotPath: "out"}, false},
+		{options{gifPath: "out.gif", gifFirst: 10, gifFrames: 20}, true},
+		{options{gifPath: "out.gif", gifFirst: -1}, false},
+		{options{gifPath: "out.gif", gifFrames: -1}, false},
+		{options{gifFirst: 10}, false},
+		{options{gifFrames: 20}, false},
+	}
+
+	for i, tt := range data {
+		if err := checkOptions(tt.options); (err == nil) != tt.isValid {
+			t.Errorf("Test %v: wrong error %v", i, err)
+		}
+		if tt.isValid {
+			continue
+		}
+
+		// Rejected before running
+		tt.options.romPath, tt.options.frames, tt.options.y4mPath = romPath, 1, y4mPath
+		if _, err := run(tt.options); err == nil {
+			t.Errorf("Test %v: run without error", i)
+		}
+		if _, err := os.Stat(y4mPath); !os.IsNotExist(err) {
+			t.Errorf("Test %v: output created", i)
+		}
+	}
+}
@@ -0,0 +1,137 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/alpetkov/nesrs_go/nesrs/controller"
+)
+
+// Button names used in input scripts.
+var buttonNames = map[string]int{
+	"a":      controller.ButtonA,
+	"b":      controller.ButtonB,
+	"select": controller.ButtonSelect,
+	"start":  controller.ButtonStart,
+	"up":     controller.ButtonUp,
+	"down":   controller.ButtonDown,
+	"left":   controller.ButtonLeft,
+	"right":  controller.ButtonRight,
+}
+
+// scriptEvent - buttons held from the frame on (until the next event), or reset.
+type scriptEvent struct {
+	frame   int
+	buttons [2]int
+	reset   bool
+}
+
+// inputScript - scripted input. Each line is "<frame> <player 1 buttons> [<player 2 buttons>]"
+// or "<frame> reset". Buttons are names joined by '+' (e.g. "a+right"), '-' releases all.
+// Lines starting with '#' are comments.
+type inputScript struct {
+	events  []scriptEvent
+	buttons [2]int
+	next    int // Next event
+}
+
+func readInputScript(reader io.Reader) (*inputScript, error) {
+	script := inputScript{}
+
+	scanner := bufio.NewScanner(reader)
+	for lineNumber := 1; scanner.Scan(); lineNumber++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || line[0] == '#' {
+			continue
+		}
+
+		event, err := parseScriptLine(line)
+		if err != nil {
+			return nil, fmt.Errorf("input script line %d: %v", lineNumber, err)
+		}
+		script.events = append(script.events, event)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	sort.SliceStable(script.events, func(i, j int) bool {
+		return script.events[i].frame < script.events[j].frame
+	})
+
+	return &script, nil
+}
+
+func parseScriptLine(line string) (scriptEvent, error) {
+	event := scriptEvent{}
+
+	fields := strings.Fields(line)
+	if len(fields) < 2 || len(fields) > 3 {
+		return event, fmt.Errorf("expected frame and buttons")
+	}
+
+	frame, err := strconv.Atoi(fields[0])
+	if err != nil || frame < 0 {
+		return event, fmt.Errorf("invalid frame %q", fields[0])
+	}
+	event.frame = frame
+
+	if strings.EqualFold(fields[1], "reset") && len(fields) == 2 {
+		event.reset = true
+		return event, nil
+	}
+
+	for player, field := range fields[1:] {
+		if event.buttons[player], err = parseButtons(field); err != nil {
+			return event, err
+		}
+	}
+
+	return event, nil
+}
+
+func parseButtons(field string) (int, error) {
+	if field == "-" {
+		return 0, nil
+	}
+
+	buttons := 0
+	for _, name := range strings.Split(field, "+") {
+		button, ok := buttonNames[strings.ToLower(name)]
+		if !ok {
+			return 0, fmt.Errorf("unknown button %q", name)
+		}
+		buttons |= button
+	}
+
+	return buttons, nil
+}
+
+// advance to the frame. Returns true if the NES should be reset.
+func (script *inputScript) advance(frame int) bool {
+	reset := false
+	for script.next < len(script.events) && script.events[script.next].frame <= frame {
+		event := script.events[script.next]
+		if event.reset {
+			reset = true
+		} else {
+			script.buttons = event.buttons
+		}
+		script.next++
+	}
+
+	return reset
+}
+
+// ReadInput .
+func (script *inputScript) ReadInput(player int) int {
+	if player < 0 || player >= len(script.buttons) {
+		return 0
+	}
+
+	return script.buttons[player]
+}
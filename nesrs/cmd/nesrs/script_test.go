@@ -0,0 +1,64 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/alpetkov/nesrs_go/nesrs/controller"
+)
+
+func TestInputScript(t *testing.T) {
+	script, err := readInputScript(strings.NewReader(`
+# Press start, then hold right with A
+10 start
+12 -
+30 right+A b
+40 reset
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data := []struct {
+		frame   int
+		player1 int
+		player2 int
+		reset   bool
+	}{
+		{0, 0, 0, false},
+		{10, controller.ButtonStart, 0, false},
+		{11, controller.ButtonStart, 0, false},
+		{12, 0, 0, false},
+		{35, controller.ButtonRight | controller.ButtonA, controller.ButtonB, false},
+		{40, controller.ButtonRight | controller.ButtonA, controller.ButtonB, true},
+	}
+	for _, tt := range data {
+		reset := script.advance(tt.frame)
+		if script.ReadInput(0) != tt.player1 || script.ReadInput(1) != tt.player2 || reset != tt.reset {
+			t.Errorf("Wrong input at frame %v: %02X %02X %v", tt.frame,
+				script.ReadInput(0), script.ReadInput(1), reset)
+		}
+	}
+
+	if _, err := readInputScript(strings.NewReader("10 jump\n")); err == nil {
+		t.Errorf("Unknown button accepted")
+	}
+}
+
+func TestParseCondition(t *testing.T) {
+	cond, err := parseCondition("$6000!=$80")
+	if err != nil || cond.address != 0x6000 || !cond.isNotEqual || cond.value != 0x80 {
+		t.Errorf("Wrong condition %+v, %v", cond, err)
+	}
+
+	cond, err = parseCondition("0x10==3")
+	if err != nil || cond.address != 0x10 || cond.isNotEqual || cond.value != 3 {
+		t.Errorf("Wrong condition %+v, %v", cond, err)
+	}
+
+	for _, text := range []string{"$6000", "$6000==$100", "x==1"} {
+		if _, err := parseCondition(text); err == nil {
+			t.Errorf("Invalid condition %q accepted", text)
+		}
+	}
+}
@@ -0,0 +1,26 @@
+package controller
+
+// Standard controller buttons. Bit order matches the order buttons are reported in.
+const (
+	ButtonA = 1 << iota
+	ButtonB
+	ButtonSelect
+	ButtonStart
+	ButtonUp
+	ButtonDown
+	ButtonLeft
+	ButtonRight
+)
+
+// InputProvider - supplies button states (mask of Button* flags) for each player.
+type InputProvider interface {
+	ReadInput(player int) int
+}
+
+// Device - input device connected to $4016 (port 1) or $4017 (port 2).
+type Device interface {
+	// Write to $4016. Bit 0 is the strobe (OUT0), bits 1 & 2 are OUT1 & OUT2.
+	Write(value int)
+	// Read from $4016/$4017. Device drives data lines D0-D4 only.
+	Read() int
+}
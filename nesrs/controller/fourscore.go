@@ -0,0 +1,54 @@
+package controller
+
+// Four Score signatures reported after both players' buttons (read LSB first).
+const (
+	fourScoreSignature1 = 0x08 // 0, 0, 0, 1, 0, 0, 0, 0
+	fourScoreSignature2 = 0x04 // 0, 0, 1, 0, 0, 0, 0, 0
+)
+
+// FourScorePort - one port of the Four Score (or Hori Satellite) four player adapter.
+// Each port reports 24 bits: buttons of two players followed by a signature byte.
+type FourScorePort struct {
+	input     InputProvider
+	players   [2]int
+	signature int
+	strobe    bool
+	shiftReg  int
+}
+
+// NewFourScore returns devices for port 1 (players 1 & 3) and port 2 (players 2 & 4).
+func NewFourScore(input InputProvider) (*FourScorePort, *FourScorePort) {
+	port1 := FourScorePort{input: input, players: [2]int{0, 2}, signature: fourScoreSignature1}
+	port2 := FourScorePort{input: input, players: [2]int{1, 3}, signature: fourScoreSignature2}
+
+	return &port1, &port2
+}
+
+// Write .
+func (port *FourScorePort) Write(value int) {
+	port.strobe = (value & 0x01) != 0
+	if port.strobe {
+		port.latch()
+	}
+}
+
+// Read .
+func (port *FourScorePort) Read() int {
+	if port.strobe {
+		port.latch()
+		return port.shiftReg & 0x01
+	}
+
+	bit := port.shiftReg & 0x01
+	port.shiftReg = (port.shiftReg >> 1) | 0x800000
+
+	return bit
+}
+
+func (port *FourScorePort) latch() {
+	port.shiftReg = port.signature << 16
+	if port.input != nil {
+		port.shiftReg |= port.input.ReadInput(port.players[0]) & 0xFF
+		port.shiftReg |= (port.input.ReadInput(port.players[1]) & 0xFF) << 8
+	}
+}
@@ -0,0 +1,46 @@
+package controller
+
+// Joypad - standard controller. Buttons are latched while strobe is high and shifted out
+// one per read (A, B, Select, Start, Up, Down, Left, Right). Further reads return 1.
+type Joypad struct {
+	input    InputProvider
+	player   int
+	strobe   bool
+	shiftReg int
+}
+
+// NewJoypad for the given player.
+func NewJoypad(input InputProvider, player int) *Joypad {
+	joypad := Joypad{input: input, player: player}
+
+	return &joypad
+}
+
+// Write .
+func (joypad *Joypad) Write(value int) {
+	joypad.strobe = (value & 0x01) != 0
+	if joypad.strobe {
+		joypad.latch()
+	}
+}
+
+// Read .
+func (joypad *Joypad) Read() int {
+	if joypad.strobe {
+		// Keeps reporting A while strobe is high
+		joypad.latch()
+		return joypad.shiftReg & 0x01
+	}
+
+	bit := joypad.shiftReg & 0x01
+	joypad.shiftReg = (joypad.shiftReg >> 1) | 0x80
+
+	return bit
+}
+
+func (joypad *Joypad) latch() {
+	joypad.shiftReg = 0
+	if joypad.input != nil {
+		joypad.shiftReg = joypad.input.ReadInput(joypad.player) & 0xFF
+	}
+}
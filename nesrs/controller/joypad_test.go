@@ -0,0 +1,110 @@
+package controller
+
+import (
+	"testing"
+)
+
+type testInput [4]int
+
+func (input *testInput) ReadInput(player int) int {
+	return input[player]
+}
+
+func TestJoypad(t *testing.T) {
+	input := &testInput{ButtonA | ButtonStart | ButtonRight, ButtonB}
+	joypad := NewJoypad(input, 0)
+
+	// Strobe high keeps reporting A
+	joypad.Write(1)
+	for i := 0; i < 3; i++ {
+		if bit := joypad.Read(); bit != 1 {
+			t.Errorf("Wrong bit %v while strobe is high", bit)
+		}
+	}
+
+	// Strobe low shifts out buttons, then 1s
+	joypad.Write(0)
+	input[0] = 0 // Already latched
+	expected := []int{1, 0, 0, 1, 0, 0, 0, 1, 1, 1}
+	for i, want := range expected {
+		if bit := joypad.Read(); bit != want {
+			t.Errorf("Wrong bit %v at read %v, right %v", bit, i, want)
+		}
+	}
+}
+
+func TestFourScore(t *testing.T) {
+	input := &testInput{ButtonA, ButtonB, ButtonSelect, ButtonStart}
+	port1, port2 := NewFourScore(input)
+
+	data := []struct {
+		port Device
+		want int
+	}{
+		{port1, ButtonA | ButtonSelect<<8 | 0x08<<16 | 0xFF<<24},
+		{port2, ButtonB | ButtonStart<<8 | 0x04<<16 | 0xFF<<24},
+	}
+
+	for _, tt := range data {
+		tt.port.Write(1)
+		tt.port.Write(0)
+
+		report := 0
+		for i := 0; i < 32; i++ {
+			report |= tt.port.Read() << uint(i)
+		}
+		if report != tt.want {
+			t.Errorf("Wrong report %08X, right %08X", report, tt.want)
+		}
+	}
+}
+
+func TestVaus(t *testing.T) {
+	vaus := NewVaus()
+	vaus.SetPosition(0xA5)
+	vaus.SetButton(true)
+
+	vaus.Write(1)
+	vaus.Write(0)
+
+	position := 0
+	for i := 0; i < 8; i++ {
+		value := vaus.Read()
+		if value&0x08 == 0 {
+			t.Errorf("Button not reported at read %v", i)
+		}
+		position = (position << 1) | (value>>4)&0x01
+	}
+	if position != ^0xA5&0xFF {
+		t.Errorf("Wrong position %02X, right %02X", position, ^0xA5&0xFF)
+	}
+
+	// 1s after the position
+	vaus.SetButton(false)
+	for i := 8; i < 10; i++ {
+		if value := vaus.Read(); value != 0x10 {
+			t.Errorf("Wrong value %02X at read %v", value, i)
+		}
+	}
+}
+
+func TestPowerPad(t *testing.T) {
+	// Buttons 1, 8 and 9 pressed
+	input := &testInput{0, 1<<0 | 1<<7 | 1<<8}
+	powerPad := NewPowerPad(input, 1)
+
+	powerPad.Write(1)
+	powerPad.Write(0)
+
+	wantD3 := []int{0, 1, 0, 1, 0, 0, 0, 0, 1, 1}
+	wantD4 := []int{0, 0, 0, 1, 1, 1, 1, 1, 1, 1}
+	for i := range wantD3 {
+		value := powerPad.Read()
+		if d3 := (value >> 3) & 0x01; d3 != wantD3[i] {
+			t.Errorf("Wrong D3 %v at read %v, right %v", d3, i, wantD3[i])
+		}
+		if d4 := (value >> 4) & 0x01; d4 != wantD4[i] {
+			t.Errorf("Wrong D4 %v at read %v, right %v", d4, i, wantD4[i])
+		}
+	}
+}
@@ -0,0 +1,79 @@
+package controller
+
+// Power Pad $4017 bits.
+const (
+	powerPadD3 = 0x08
+	powerPadD4 = 0x10
+)
+
+// Order (button numbers) the Power Pad reports its buttons in on D3 and D4.
+var (
+	powerPadD3Order = [8]int{2, 1, 5, 9, 6, 10, 11, 7}
+	powerPadD4Order = [4]int{4, 3, 12, 8}
+)
+
+// PowerPad - Power Pad (Family Trainer) mat with 12 buttons. The InputProvider reports button n
+// (1-12) as bit n-1 of the player's input. Buttons are latched while strobe is high and shifted
+// out on D3 (8 buttons) and D4 (4 buttons), pressed being 1. Further reads return 1.
+type PowerPad struct {
+	input      InputProvider
+	player     int
+	strobe     bool
+	shiftRegD3 int
+	shiftRegD4 int
+}
+
+// NewPowerPad for the given player.
+func NewPowerPad(input InputProvider, player int) *PowerPad {
+	powerPad := PowerPad{input: input, player: player}
+
+	return &powerPad
+}
+
+// Write .
+func (powerPad *PowerPad) Write(value int) {
+	powerPad.strobe = (value & 0x01) != 0
+	if powerPad.strobe {
+		powerPad.latch()
+	}
+}
+
+// Read .
+func (powerPad *PowerPad) Read() int {
+	if powerPad.strobe {
+		powerPad.latch()
+	}
+
+	result := 0
+	if powerPad.shiftRegD3&0x01 != 0 {
+		result |= powerPadD3
+	}
+	if powerPad.shiftRegD4&0x01 != 0 {
+		result |= powerPadD4
+	}
+
+	if !powerPad.strobe {
+		powerPad.shiftRegD3 = (powerPad.shiftRegD3 >> 1) | 0x80
+		powerPad.shiftRegD4 = (powerPad.shiftRegD4 >> 1) | 0x80
+	}
+
+	return result
+}
+
+func (powerPad *PowerPad) latch() {
+	buttons := 0
+	if powerPad.input != nil {
+		buttons = powerPad.input.ReadInput(powerPad.player)
+	}
+
+	powerPad.shiftRegD3 = 0
+	for i, button := range powerPadD3Order {
+		powerPad.shiftRegD3 |= ((buttons >> uint(button-1)) & 0x01) << uint(i)
+	}
+
+	// Only 4 buttons on D4, the rest reads as 1
+	powerPad.shiftRegD4 = 0xF0
+	for i, button := range powerPadD4Order {
+		powerPad.shiftRegD4 |= ((buttons >> uint(button-1)) & 0x01) << uint(i)
+	}
+}
@@ -0,0 +1,58 @@
+package controller
+
+import (
+	"github.com/alpetkov/nesrs_go/nesrs/state"
+)
+
+// Host controlled inputs (buttons, Zapper aim, Vaus knob) aren't part of the state, only
+// what the device has latched.
+
+// SaveState .
+func (joypad *Joypad) SaveState(writer *state.Writer) {
+	writer.WriteBool(joypad.strobe)
+	writer.WriteInt(joypad.shiftReg)
+}
+
+// LoadState .
+func (joypad *Joypad) LoadState(reader *state.Reader) {
+	joypad.strobe = reader.ReadBool()
+	joypad.shiftReg = reader.ReadInt()
+}
+
+// SaveState .
+func (port *FourScorePort) SaveState(writer *state.Writer) {
+	writer.WriteBool(port.strobe)
+	writer.WriteInt(port.shiftReg)
+}
+
+// LoadState .
+func (port *FourScorePort) LoadState(reader *state.Reader) {
+	port.strobe = reader.ReadBool()
+	port.shiftReg = reader.ReadInt()
+}
+
+// SaveState .
+func (powerPad *PowerPad) SaveState(writer *state.Writer) {
+	writer.WriteBool(powerPad.strobe)
+	writer.WriteInt(powerPad.shiftRegD3)
+	writer.WriteInt(powerPad.shiftRegD4)
+}
+
+// LoadState .
+func (powerPad *PowerPad) LoadState(reader *state.Reader) {
+	powerPad.strobe = reader.ReadBool()
+	powerPad.shiftRegD3 = reader.ReadInt()
+	powerPad.shiftRegD4 = reader.ReadInt()
+}
+
+// SaveState .
+func (vaus *Vaus) SaveState(writer *state.Writer) {
+	writer.WriteBool(vaus.strobe)
+	writer.WriteInt(vaus.shiftReg)
+}
+
+// LoadState .
+func (vaus *Vaus) LoadState(reader *state.Reader) {
+	vaus.strobe = reader.ReadBool()
+	vaus.shiftReg = reader.ReadInt()
+}
@@ -0,0 +1,85 @@
+package controller
+
+import (
+	"sync"
+)
+
+// Vaus $4017 bits.
+const (
+	vausButton = 0x08 // D3
+	vausData   = 0x10 // D4
+)
+
+// Vaus - Arkanoid paddle controller. The knob position (8-bit potentiometer value) is latched
+// while strobe is high and shifted out inverted, MSB first, on D4 (1s after the 8 bits). The
+// button is reported on D3.
+// Knob and button can be set from any goroutine.
+type Vaus struct {
+	lock     sync.Mutex
+	position int
+	button   bool
+	strobe   bool
+	shiftReg int
+}
+
+// NewVaus with the knob centered.
+func NewVaus() *Vaus {
+	vaus := Vaus{position: 0x80}
+
+	return &vaus
+}
+
+// SetPosition of the knob (0x00-0xFF). Arkanoid expects values in about 0x62-0xF2 range.
+func (vaus *Vaus) SetPosition(position int) {
+	vaus.lock.Lock()
+	defer vaus.lock.Unlock()
+
+	vaus.position = position & 0xFF
+}
+
+// SetButton state.
+func (vaus *Vaus) SetButton(pressed bool) {
+	vaus.lock.Lock()
+	defer vaus.lock.Unlock()
+
+	vaus.button = pressed
+}
+
+// Write .
+func (vaus *Vaus) Write(value int) {
+	vaus.lock.Lock()
+	defer vaus.lock.Unlock()
+
+	vaus.strobe = (value & 0x01) != 0
+	if vaus.strobe {
+		vaus.latch()
+	}
+}
+
+// Read .
+func (vaus *Vaus) Read() int {
+	vaus.lock.Lock()
+	defer vaus.lock.Unlock()
+
+	if vaus.strobe {
+		vaus.latch()
+	}
+
+	result := 0
+	if vaus.shiftReg&0x80 != 0 {
+		result |= vausData
+	}
+	if vaus.button {
+		result |= vausButton
+	}
+
+	if !vaus.strobe {
+		vaus.shiftReg = ((vaus.shiftReg << 1) | 0x01) & 0xFF
+	}
+
+	return result
+}
+
+func (vaus *Vaus) latch() {
+	vaus.shiftReg = ^vaus.position & 0xFF
+}
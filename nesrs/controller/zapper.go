@@ -0,0 +1,110 @@
+package controller
+
+import (
+	"sync"
+)
+
+// Zapper light sensing parameters.
+const (
+	zapperLightScanlines = 20   // Photodiode keeps sensing light for about 20 scanlines after the beam passed
+	zapperRadius         = 2    // Pixels around the target seen by the photodiode
+	zapperBrightness     = 0xA0 // Minimum luma of a lit pixel
+)
+
+// Zapper $4017 bits.
+const (
+	zapperLightNotSensed = 0x08 // D3
+	zapperTriggerPulled  = 0x10 // D4
+)
+
+// FrameSource - picture being rendered, used by light guns.
+type FrameSource interface {
+	// Pixel (RGB) at (x, y) of the current frame. Not yet rendered pixels are black.
+	Pixel(x int, y int) int
+	// BeamPosition currently rendered. y is outside the screen during vertical blank.
+	BeamPosition() (x int, y int)
+}
+
+// Zapper - light gun (usually in port 2). Light is sensed when the target around the aimed
+// point is bright and the beam has passed it recently. Aim and trigger can be set from any goroutine.
+type Zapper struct {
+	lock    sync.Mutex
+	frame   FrameSource
+	x       int
+	y       int
+	trigger bool
+}
+
+// NewZapper aiming off screen.
+func NewZapper(frame FrameSource) *Zapper {
+	zapper := Zapper{frame: frame, x: -1, y: -1}
+
+	return &zapper
+}
+
+// Aim at screen coordinates. Coordinates outside the screen aim off screen.
+func (zapper *Zapper) Aim(x int, y int) {
+	zapper.lock.Lock()
+	defer zapper.lock.Unlock()
+
+	zapper.x = x
+	zapper.y = y
+}
+
+// SetTrigger state.
+func (zapper *Zapper) SetTrigger(pulled bool) {
+	zapper.lock.Lock()
+	defer zapper.lock.Unlock()
+
+	zapper.trigger = pulled
+}
+
+// Write .
+func (zapper *Zapper) Write(value int) {
+	// Zapper has no strobe
+}
+
+// Read .
+func (zapper *Zapper) Read() int {
+	zapper.lock.Lock()
+	defer zapper.lock.Unlock()
+
+	result := zapperLightNotSensed
+	if zapper.isLightSensed() {
+		result = 0
+	}
+	if zapper.trigger {
+		result |= zapperTriggerPulled
+	}
+
+	return result
+}
+
+func (zapper *Zapper) isLightSensed() bool {
+	if zapper.frame == nil || zapper.x < 0 || zapper.y < 0 {
+		return false
+	}
+
+	_, beamY := zapper.frame.BeamPosition()
+	if beamY < zapper.y || beamY-zapper.y >= zapperLightScanlines {
+		return false
+	}
+
+	for y := zapper.y - zapperRadius; y <= zapper.y+zapperRadius; y++ {
+		for x := zapper.x - zapperRadius; x <= zapper.x+zapperRadius; x++ {
+			if luma(zapper.frame.Pixel(x, y)) >= zapperBrightness {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+func luma(rgb int) int {
+	r := (rgb >> 16) & 0xFF
+	g := (rgb >> 8) & 0xFF
+	b := rgb & 0xFF
+
+	return (299*r + 587*g + 114*b) / 1000
+}
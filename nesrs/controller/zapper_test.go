@@ -0,0 +1,46 @@
+package controller
+
+import (
+	"testing"
+)
+
+type testFrame struct {
+	pixels map[[2]int]int
+	beamY  int
+}
+
+func (frame *testFrame) Pixel(x int, y int) int {
+	return frame.pixels[[2]int{x, y}]
+}
+
+func (frame *testFrame) BeamPosition() (int, int) {
+	return 0, frame.beamY
+}
+
+func TestZapper(t *testing.T) {
+	frame := &testFrame{pixels: map[[2]int]int{{100, 50}: 0xFFFFFF, {10, 10}: 0x0000FF}}
+	zapper := NewZapper(frame)
+
+	tests := []struct {
+		x, y    int
+		beamY   int
+		trigger bool
+		want    int
+	}{
+		{-1, -1, 60, false, 0x08},  // Off screen
+		{101, 51, 60, false, 0x00}, // Near white pixel
+		{101, 51, 60, true, 0x10},
+		{100, 50, 49, false, 0x08}, // Beam has not reached it
+		{100, 50, 80, false, 0x08}, // Light faded
+		{10, 10, 15, false, 0x08},  // Dark blue
+		{200, 200, 210, false, 0x08},
+	}
+	for i, test := range tests {
+		zapper.Aim(test.x, test.y)
+		zapper.SetTrigger(test.trigger)
+		frame.beamY = test.beamY
+		if got := zapper.Read(); got != test.want {
+			t.Errorf("Test %v: got 0x%02X, want 0x%02X", i, got, test.want)
+		}
+	}
+}
@@ -75,6 +75,12 @@ type CPU struct {
 	// Pending interrupt
 	pendingInterrupt int
 
+	// Asserted IRQ lines (level triggered). Each bit is a separate IRQ source.
+	irqLines int
+
+	// Cycles the CPU is halted for (e.g. DMC DMA)
+	stallCycles int
+
 	// 64Kb of CPU's addressable memory
 	memory CPUMemory
 
@@ -117,8 +123,34 @@ func (cpu *CPU) IRQ() {
 	cpu.requestInterrupt(IRQ)
 }
 
+// SetIRQLine - asserts or releases the IRQ line of the given source (bit mask).
+// IRQ is requested before each op as long as any line is asserted and IRQs are enabled.
+func (cpu *CPU) SetIRQLine(source int, asserted bool) {
+	if asserted {
+		cpu.irqLines |= source
+	} else {
+		cpu.irqLines &^= source
+	}
+}
+
+// Stall - halts the CPU for the given number of cycles. Stolen cycles are
+// reported as OpCycles of the next ExecuteOp, so the rest of the system keeps running.
+func (cpu *CPU) Stall(cycles int) {
+	cpu.stallCycles += cycles
+}
+
 // ExecuteOp - Execute CPU OP
 func (cpu *CPU) ExecuteOp() int {
+	if cpu.stallCycles > 0 {
+		cpu.OpCycles = cpu.stallCycles
+		cpu.stallCycles = 0
+		return cpu.OpCycles
+	}
+
+	if cpu.irqLines != 0 && (cpu.P&flagI) == 0 {
+		cpu.IRQ()
+	}
+
 	if cpu.pendingInterrupt != 0 {
 		cpu.executePendingInterruptOp()
 	} else {
@@ -158,7 +190,7 @@ func (cpu *CPU) executePendingInterruptOp() {
 		cpu.X = 0x00
 		cpu.Y = 0x00
 		cpu.S = 0xFF
-		cpu.P = flagZ | flagR
+		cpu.P = flagZ | flagR | flagI
 		cpu.PC = (cpu.readMemory(0xFFFD) << 8) | cpu.readMemory(0xFFFC)
 
 	} else if cpu.pendingInterrupt == NMI {
@@ -175,7 +207,7 @@ func (cpu *CPU) executePendingInterruptOp() {
 		cpu.push(cpu.PC & 0x00FF)
 		cpu.push(cpu.P &^ flagB)
 		cpu.P = cpu.P &^ flagD
-		cpu.P = cpu.P &^ flagI
+		cpu.P = cpu.P | flagI
 		cpu.PC = (cpu.readMemory(0xFFFF) << 8) | cpu.readMemory(0xFFFE)
 	}
 
@@ -0,0 +1,113 @@
+package cpu
+
+import (
+	"testing"
+)
+
+// testMemory - flat 64KB RAM.
+type testMemory [0x10000]int
+
+func (memory *testMemory) Read(address int) int {
+	return memory[address&0xFFFF]
+}
+
+func (memory *testMemory) Write(address int, value int) int {
+	memory[address&0xFFFF] = value
+	return 0
+}
+
+func newIRQTestCPU() (*CPU, *testMemory) {
+	memory := &testMemory{}
+	// $8000: CLI, loop: NOP, JMP loop
+	copy(memory[0x8000:], []int{0x58, 0xEA, 0x4C, 0x01, 0x80})
+	// $9000: INC $10, RTI
+	copy(memory[0x9000:], []int{0xE6, 0x10, 0x40})
+	memory[0xFFFC], memory[0xFFFD] = 0x00, 0x80
+	memory[0xFFFE], memory[0xFFFF] = 0x00, 0x90
+
+	cpu := New(memory)
+	cpu.Init()
+	cpu.ExecuteOp() // CLI
+
+	return cpu, memory
+}
+
+func TestIRQLine(t *testing.T) {
+	cpu, memory := newIRQTestCPU()
+
+	cpu.SetIRQLine(0x01, true)
+	if cycles := cpu.ExecuteOp(); cycles != 7 || cpu.PC != 0x9000 {
+		t.Fatalf("IRQ not taken (cycles %v, PC %04X)", cycles, cpu.PC)
+	}
+	if (cpu.P & flagI) == 0 {
+		t.Errorf("I flag not set on IRQ entry")
+	}
+	if pushed := memory[0x0100+cpu.S+1]; (pushed&flagI) != 0 || (pushed&flagB) != 0 {
+		t.Errorf("Wrong pushed status %02X", pushed)
+	}
+
+	// Handler isn't interrupted while I is set, RTI re-enables and the asserted line fires again
+	cpu.ExecuteOp() // INC
+	cpu.ExecuteOp() // RTI
+	cpu.ExecuteOp()
+	if cpu.PC != 0x9000 || memory[0x10] != 1 {
+		t.Fatalf("IRQ not taken again while line asserted (PC %04X)", cpu.PC)
+	}
+
+	// Acknowledge (release) in the handler
+	cpu.ExecuteOp() // INC
+	cpu.SetIRQLine(0x01, false)
+	cpu.ExecuteOp() // RTI
+	for i := 0; i < 10; i++ {
+		cpu.ExecuteOp()
+		if cpu.PC >= 0x9000 {
+			t.Fatalf("IRQ taken after release")
+		}
+	}
+	if memory[0x10] != 2 {
+		t.Errorf("Wrong IRQ count %v", memory[0x10])
+	}
+}
+
+func TestIRQLineSources(t *testing.T) {
+	cpu, _ := newIRQTestCPU()
+
+	// Masked by I
+	cpu.P |= flagI
+	cpu.SetIRQLine(0x01, true)
+	cpu.SetIRQLine(0x02, true)
+	for i := 0; i < 10; i++ {
+		cpu.ExecuteOp()
+		if cpu.PC >= 0x9000 {
+			t.Fatalf("IRQ taken while masked")
+		}
+	}
+
+	// Releasing one source keeps the line asserted by the other
+	cpu.SetIRQLine(0x01, false)
+	cpu.P &^= flagI
+	cpu.ExecuteOp()
+	if cpu.PC != 0x9000 {
+		t.Errorf("IRQ of the second source not taken (PC %04X)", cpu.PC)
+	}
+}
+
+func TestIRQLineReset(t *testing.T) {
+	cpu, _ := newIRQTestCPU()
+
+	// Reset sets I, so the reset handler starts even while the line is asserted
+	cpu.SetIRQLine(0x01, true)
+	cpu.Reset()
+	cpu.ExecuteOp()
+	if cpu.PC != 0x8000 || (cpu.P&flagI) == 0 {
+		t.Fatalf("Wrong reset (PC %04X, P %02X)", cpu.PC, cpu.P)
+	}
+	cpu.ExecuteOp() // CLI
+	if cpu.PC != 0x8001 {
+		t.Fatalf("IRQ taken before the first instruction (PC %04X)", cpu.PC)
+	}
+	cpu.ExecuteOp()
+	if cpu.PC != 0x9000 {
+		t.Errorf("IRQ not taken after CLI (PC %04X)", cpu.PC)
+	}
+}
@@ -1,10 +1,16 @@
 package cpu
 
 import (
+	"github.com/alpetkov/nesrs_go/nesrs/apu"
 	"github.com/alpetkov/nesrs_go/nesrs/cartridge"
+	"github.com/alpetkov/nesrs_go/nesrs/controller"
 	"github.com/alpetkov/nesrs_go/nesrs/ppu"
 )
 
+// Controller reads only drive D0-D4. Upper bits keep the last value on the data bus
+// (high byte of $4016/$4017).
+const controllerOpenBus = 0x40
+
 // CPUMemory is the CPU addressable memory.
 type CPUMemory interface {
 	Read(adress int) int
@@ -29,9 +35,12 @@ func (memory *TestCPUMemory) Write(address int, value int) int {
 
 // NESCPUMemory for NES.
 type NESCPUMemory struct {
-	ram       [0x800]int
-	cartridge *cartridge.Cartridge
-	ppu       *ppu.PPU
+	ram         [0x800]int
+	cartridge   *cartridge.Cartridge
+	ppu         *ppu.PPU
+	apu         *apu.APU
+	controller1 controller.Device
+	controller2 controller.Device
 }
 
 // SetCartridge .
@@ -44,6 +53,31 @@ func (memory *NESCPUMemory) SetPPU(ppu *ppu.PPU) {
 	memory.ppu = ppu
 }
 
+// SetAPU .
+func (memory *NESCPUMemory) SetAPU(apu *apu.APU) {
+	memory.apu = apu
+}
+
+// SetController1 .
+func (memory *NESCPUMemory) SetController1(controller1 controller.Device) {
+	memory.controller1 = controller1
+}
+
+// SetController2 .
+func (memory *NESCPUMemory) SetController2(controller2 controller.Device) {
+	memory.controller2 = controller2
+}
+
+// Controller1 .
+func (memory *NESCPUMemory) Controller1() controller.Device {
+	return memory.controller1
+}
+
+// Controller2 .
+func (memory *NESCPUMemory) Controller2() controller.Device {
+	return memory.controller2
+}
+
 // Read from NES.
 func (memory *NESCPUMemory) Read(address int) int {
 	page := address & 0xF000
@@ -63,23 +97,24 @@ func (memory *NESCPUMemory) Read(address int) int {
 
 		if address == 0x4015 {
 			// APU
-			// if memory.apu != nil {
-			// 	return memory.apu.ReadRegister(address)
-			// }
+			if memory.apu != nil {
+				return memory.apu.ReadRegister(address)
+			}
 
 			return 0
 		} else if address == 0x4016 {
 			// Controller 1
-			// if memory.controller1 != nils {
-			// 	return memory.controller1.Read()
-			// }
-			return 0
+			if memory.controller1 != nil {
+				return controllerOpenBus | (memory.controller1.Read() & 0x1F)
+			}
+			return controllerOpenBus
 
 		} else if address == 0x4017 {
 			// Controller 2
-			// if memory.controller2 != nil {
-			// 	return memory.controller2.Read()
-			// }
+			if memory.controller2 != nil {
+				return controllerOpenBus | (memory.controller2.Read() & 0x1F)
+			}
+			return controllerOpenBus
 
 		} else if address >= 0x4020 {
 			// Expansion ROM/Cartridge
@@ -99,6 +134,17 @@ func (memory *NESCPUMemory) Read(address int) int {
 	return 0
 }
 
+// Peek reads RAM or cartridge memory without side effects. Registers read as 0.
+func (memory *NESCPUMemory) Peek(address int) int {
+	if address < 0x2000 {
+		return memory.ram[address&0x07FF]
+	} else if address >= 0x4020 && memory.cartridge != nil {
+		return memory.cartridge.ReadPrgMemory(address & 0xFFFF)
+	}
+
+	return 0
+}
+
 // Write to NES.
 func (memory *NESCPUMemory) Write(address int, value int) int {
 	page := (address & 0xF000)
@@ -119,9 +165,9 @@ func (memory *NESCPUMemory) Write(address int, value int) int {
 
 		if address <= 0x4013 || address == 0x4015 || address == 0x4017 {
 			// APU
-			// if memory.apu != nil {
-			// 	memory.apu.WriteRegister(address, value)
-			// }
+			if memory.apu != nil {
+				memory.apu.WriteRegister(address, value)
+			}
 
 		} else if address == 0x4014 {
 			// DMA
@@ -141,14 +187,14 @@ func (memory *NESCPUMemory) Write(address int, value int) int {
 
 		} else if address == 0x4016 {
 			// Controller 1
-			// if ppu.controller1 != nil {
-			// 	ppu.controller1.Write(value & 0x01)
-			// }
+			if memory.controller1 != nil {
+				memory.controller1.Write(value & 0x07)
+			}
 
 			// Controller 2
-			// if ppu.controller2 != nil {
-			// 	ppu.controller2.Write(value & 0x01)
-			// }
+			if memory.controller2 != nil {
+				memory.controller2.Write(value & 0x07)
+			}
 
 		} else if address >= 0x4020 {
 			// Expansion ROM
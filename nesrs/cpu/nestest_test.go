@@ -91,7 +91,12 @@ func readNestestRom(filePath string) *cartridge.Cartridge {
 	}
 	defer file.Close()
 
-	return cartridge.New(file)
+	cartridge, err := cartridge.New(file)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	return cartridge
 }
 
 func toHex(value int) string {
@@ -0,0 +1,43 @@
+package cpu
+
+import (
+	"github.com/alpetkov/nesrs_go/nesrs/state"
+)
+
+// SaveState of the CPU.
+func (cpu *CPU) SaveState(writer *state.Writer) {
+	writer.WriteInt(cpu.A)
+	writer.WriteInt(cpu.X)
+	writer.WriteInt(cpu.Y)
+	writer.WriteInt(cpu.S)
+	writer.WriteInt(cpu.P)
+	writer.WriteInt(cpu.PC)
+	writer.WriteInt(cpu.OpCycles)
+	writer.WriteInt(cpu.pendingInterrupt)
+	writer.WriteInt(cpu.irqLines)
+	writer.WriteInt(cpu.stallCycles)
+}
+
+// LoadState of the CPU.
+func (cpu *CPU) LoadState(reader *state.Reader) {
+	cpu.A = reader.ReadIntRange(0, 0x100)
+	cpu.X = reader.ReadIntRange(0, 0x100)
+	cpu.Y = reader.ReadIntRange(0, 0x100)
+	cpu.S = reader.ReadIntRange(0, 0x100)
+	cpu.P = reader.ReadIntRange(0, 0x100)
+	cpu.PC = reader.ReadIntRange(0, 0x10000)
+	cpu.OpCycles = reader.ReadIntRange(0, 0x10000)
+	cpu.pendingInterrupt = reader.ReadIntRange(0, IRQ+1)
+	cpu.irqLines = reader.ReadInt()
+	cpu.stallCycles = reader.ReadIntRange(0, 0x10000)
+}
+
+// SaveState of the internal RAM.
+func (memory *NESCPUMemory) SaveState(writer *state.Writer) {
+	writer.WriteArray(memory.ram[:])
+}
+
+// LoadState of the internal RAM.
+func (memory *NESCPUMemory) LoadState(reader *state.Reader) {
+	reader.ReadArray(memory.ram[:])
+}
@@ -0,0 +1,54 @@
+package nesrs
+
+import (
+	"github.com/alpetkov/nesrs_go/nesrs/controller"
+	"github.com/alpetkov/nesrs_go/nesrs/movie"
+	"github.com/alpetkov/nesrs_go/nesrs/state"
+)
+
+// Maximum number of players (Four Score).
+const maxPlayers = movie.MaxPlayers
+
+// frameInput samples the input provider once per frame, so every read during a frame
+// sees the same button states regardless of how often the game strobes the controllers.
+type frameInput struct {
+	provider       controller.InputProvider
+	players        int // Polled players (2 or 4 with Four Score)
+	buttons        [maxPlayers]int
+	isResetPending bool // Reset button, reported along the buttons of the next frame
+}
+
+// poll input of the next frame.
+func (input *frameInput) poll() movie.Frame {
+	frame := movie.Frame{}
+	for player := 0; player < input.players; player++ {
+		if input.provider != nil {
+			frame.Buttons[player] = input.provider.ReadInput(player)
+		}
+	}
+	if input.isResetPending {
+		input.isResetPending = false
+		frame.Commands |= movie.CommandReset
+	}
+
+	return frame
+}
+
+// ReadInput .
+func (input *frameInput) ReadInput(player int) int {
+	if player < 0 || player >= maxPlayers {
+		return 0
+	}
+
+	return input.buttons[player]
+}
+
+func (input *frameInput) saveState(writer *state.Writer) {
+	writer.WriteArray(input.buttons[:])
+	writer.WriteBool(input.isResetPending)
+}
+
+func (input *frameInput) loadState(reader *state.Reader) {
+	reader.ReadArray(input.buttons[:])
+	input.isResetPending = reader.ReadBool()
+}
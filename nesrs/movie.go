@@ -0,0 +1,144 @@
+package nesrs
+
+import (
+	"bytes"
+	"errors"
+
+	"github.com/alpetkov/nesrs_go/nesrs/controller"
+	"github.com/alpetkov/nesrs_go/nesrs/movie"
+)
+
+// Movie modes.
+const (
+	movieNone = iota
+	movieRecording
+	moviePlaying
+)
+
+// ErrMovieROM is returned when the movie was recorded with a different ROM.
+var ErrMovieROM = errors.New("nesrs: movie is for a different ROM")
+
+// RecordMovie starts recording input into a new movie. When the NES is running the movie starts
+// from a save state of the current frame, otherwise from power on (next Start).
+// Only standard controllers (and Four Score) input is recorded.
+func (nes *NES) RecordMovie() (*movie.Movie, error) {
+	nes.lock.Lock()
+	defer nes.lock.Unlock()
+
+	recording := movie.New()
+	recording.ROMChecksum = nes.romChecksum
+	recording.FourScore = nes.isFourScore
+	if _, ok := nes.cpuMemory.Controller2().(*controller.Joypad); !ok && !nes.isFourScore {
+		recording.Ports[1] = movie.DeviceNone
+	}
+
+	if nes.state != stopped {
+		var snapshot bytes.Buffer
+		if err := nes.saveState(&snapshot); err != nil {
+			return nil, err
+		}
+		recording.SaveState = snapshot.Bytes()
+		recording.Frames = []movie.Frame{{Buttons: nes.input.buttons}}
+	}
+
+	nes.movie = recording
+	nes.movieMode = movieRecording
+	nes.movieStart = nes.frame
+
+	return recording, nil
+}
+
+// PlayMovie powers the NES on (and loads the movie's save state, if any) and replays the movie's
+// input. The input provider takes over once the movie ends. Movies recorded by FCEUX play
+// only when they start from power on.
+func (nes *NES) PlayMovie(recorded *movie.Movie) error {
+	nes.lock.Lock()
+	defer nes.lock.Unlock()
+
+	if recorded.ROMChecksum != "" && recorded.ROMChecksum != nes.romChecksum {
+		return ErrMovieROM
+	}
+	if recorded.FourScore != nes.isFourScore {
+		nes.setFourScore(recorded.FourScore)
+	}
+
+	nes.movie = recorded
+	nes.movieMode = moviePlaying
+	nes.start()
+
+	if recorded.SaveState != nil {
+		if err := nes.loadState(bytes.NewReader(recorded.SaveState)); err != nil {
+			nes.stopMovie()
+			return err
+		}
+	}
+
+	return nil
+}
+
+// StopMovie stops recording or playback.
+func (nes *NES) StopMovie() {
+	nes.lock.Lock()
+	defer nes.lock.Unlock()
+
+	nes.stopMovie()
+}
+
+func (nes *NES) stopMovie() {
+	nes.movie = nil
+	nes.movieMode = movieNone
+}
+
+// IsMoviePlaying returns true until the played movie ends.
+func (nes *NES) IsMoviePlaying() bool {
+	nes.lock.Lock()
+	defer nes.lock.Unlock()
+
+	return nes.movieMode == moviePlaying
+}
+
+// startMovie from power on.
+func (nes *NES) startMovie() {
+	nes.movieStart = 0
+	if nes.movieMode == movieRecording {
+		nes.movie.SaveState = nil
+		nes.movie.Frames = nil
+	}
+}
+
+// movieInput of the current frame when a movie is played.
+func (nes *NES) movieInput() (movie.Frame, bool) {
+	if nes.movieMode != moviePlaying {
+		return movie.Frame{}, false
+	}
+
+	index := nes.frame - nes.movieStart
+	if index < 0 || index >= len(nes.movie.Frames) {
+		nes.movieMode = movieNone
+		return movie.Frame{}, false
+	}
+
+	return nes.movie.Frames[index], true
+}
+
+func (nes *NES) recordMovieInput(input movie.Frame) {
+	if nes.movieMode == movieRecording {
+		nes.movie.Frames = append(nes.movie.Frames, input)
+	}
+}
+
+// truncateMovie being recorded after rewinding (re-record).
+func (nes *NES) truncateMovie() {
+	if nes.movieMode != movieRecording {
+		return
+	}
+
+	count := nes.frame - nes.movieStart + 1
+	if count < 0 {
+		count = 0
+	}
+	if count < len(nes.movie.Frames) {
+		nes.movie.Frames = nes.movie.Frames[:count]
+	}
+	nes.movie.RerecordCount++
+}
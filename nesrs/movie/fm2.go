@@ -0,0 +1,228 @@
+package movie
+
+import (
+	"bufio"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// Version written to fm2 header.
+const (
+	fm2Version = 3
+	emuVersion = 1
+)
+
+// Button characters in fm2 order. Leftmost is Right (bit 7), rightmost is A (bit 0).
+const fm2Buttons = "RLDUTSBA"
+
+// Prefix of nesrs save states (see nesrs.SaveState).
+const saveStateMagic = "NESRS\x1A"
+
+// Errors.
+var (
+	ErrInvalidFM2      = errors.New("movie: invalid fm2")
+	ErrBinaryFM2       = errors.New("movie: binary fm2 is not supported")
+	ErrDevice          = errors.New("movie: unsupported port device")
+	ErrSaveStateFormat = errors.New("movie: savestate is not an nesrs save state")
+)
+
+// Read fm2 movie. Movies recorded by FCEUX are supported only when they start from power on,
+// as their embedded savestate can't be loaded.
+func Read(reader io.Reader) (*Movie, error) {
+	movie := Movie{Ports: [3]int{DeviceGamepad, DeviceGamepad, DeviceNone}}
+	hasVersion := false
+
+	scanner := bufio.NewScanner(reader)
+	scanner.Buffer(make([]byte, 64*1024), 64*1024*1024) // Embedded save states are long
+	for lineNumber := 1; scanner.Scan(); lineNumber++ {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if line == "" {
+			continue
+		}
+
+		if line[0] == '|' {
+			frame, err := movie.parseFrame(line)
+			if err != nil {
+				return nil, fmt.Errorf("movie: line %d: %v", lineNumber, err)
+			}
+			movie.Frames = append(movie.Frames, frame)
+			continue
+		}
+
+		key, value := line, ""
+		if i := strings.IndexByte(line, ' '); i >= 0 {
+			key, value = line[:i], line[i+1:]
+		}
+
+		var err error
+		switch key {
+		case "version":
+			hasVersion = true
+			var version int
+			if version, err = strconv.Atoi(value); err == nil && version != fm2Version {
+				err = ErrInvalidFM2
+			}
+		case "emuVersion":
+			movie.EmuVersion, err = strconv.Atoi(value)
+		case "rerecordCount":
+			movie.RerecordCount, err = strconv.Atoi(value)
+		case "romFilename":
+			movie.ROMFilename = value
+		case "romChecksum":
+			movie.ROMChecksum = value
+		case "guid":
+			movie.GUID = value
+		case "palFlag":
+			movie.PAL = value == "1"
+		case "fourscore":
+			movie.FourScore = value == "1"
+		case "port0", "port1", "port2":
+			port := int(key[4] - '0')
+			if movie.Ports[port], err = strconv.Atoi(value); err == nil && movie.Ports[port] > DeviceGamepad {
+				err = ErrDevice
+			}
+		case "binary":
+			if value == "1" {
+				err = ErrBinaryFM2
+			}
+		case "comment":
+			movie.Comments = append(movie.Comments, value)
+		case "savestate":
+			// FCEUX savestates are hex (0x...) or base64 without the nesrs prefix
+			movie.SaveState, err = decodeBase64(value)
+			if err == ErrInvalidFM2 || err == nil && !strings.HasPrefix(string(movie.SaveState), saveStateMagic) {
+				err = ErrSaveStateFormat
+			}
+		}
+		if err != nil {
+			return nil, fmt.Errorf("movie: line %d: %v", lineNumber, err)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if !hasVersion {
+		return nil, ErrInvalidFM2
+	}
+
+	return &movie, nil
+}
+
+// parseFrame - |commands|port0|port1|port2| or |commands|p1|p2|p3|p4|port2| with Four Score.
+func (movie *Movie) parseFrame(line string) (Frame, error) {
+	frame := Frame{}
+
+	fields := strings.Split(line, "|")
+	gamepads := 2
+	if movie.FourScore {
+		gamepads = 4
+	}
+	if len(fields) < gamepads+3 {
+		return frame, ErrInvalidFM2
+	}
+
+	commands, err := strconv.Atoi(fields[1])
+	if err != nil {
+		return frame, ErrInvalidFM2
+	}
+	frame.Commands = commands
+
+	for player := 0; player < gamepads; player++ {
+		if !movie.FourScore && movie.Ports[player] != DeviceGamepad {
+			continue
+		}
+		if frame.Buttons[player], err = parseButtons(fields[2+player]); err != nil {
+			return frame, err
+		}
+	}
+
+	return frame, nil
+}
+
+func parseButtons(field string) (int, error) {
+	if len(field) != len(fm2Buttons) {
+		return 0, ErrInvalidFM2
+	}
+
+	buttons := 0
+	for i := 0; i < len(field); i++ {
+		if field[i] != '.' && field[i] != ' ' {
+			buttons |= 0x80 >> uint(i)
+		}
+	}
+
+	return buttons, nil
+}
+
+func formatButtons(buttons int) string {
+	field := []byte(fm2Buttons)
+	for i := range field {
+		if buttons&(0x80>>uint(i)) == 0 {
+			field[i] = '.'
+		}
+	}
+
+	return string(field)
+}
+
+func decodeBase64(value string) ([]byte, error) {
+	if !strings.HasPrefix(value, "base64:") {
+		return nil, ErrInvalidFM2
+	}
+
+	return base64.StdEncoding.DecodeString(strings.TrimPrefix(value, "base64:"))
+}
+
+// Write fm2 movie.
+func (movie *Movie) Write(writer io.Writer) error {
+	out := bufio.NewWriter(writer)
+
+	fmt.Fprintf(out, "version %d\n", fm2Version)
+	fmt.Fprintf(out, "emuVersion %d\n", movie.EmuVersion)
+	fmt.Fprintf(out, "rerecordCount %d\n", movie.RerecordCount)
+	fmt.Fprintf(out, "palFlag %d\n", boolToInt(movie.PAL))
+	fmt.Fprintf(out, "romFilename %s\n", movie.ROMFilename)
+	fmt.Fprintf(out, "romChecksum %s\n", movie.ROMChecksum)
+	fmt.Fprintf(out, "guid %s\n", movie.GUID)
+	fmt.Fprintf(out, "fourscore %d\n", boolToInt(movie.FourScore))
+	for port, device := range movie.Ports {
+		fmt.Fprintf(out, "port%d %d\n", port, device)
+	}
+	for _, comment := range movie.Comments {
+		fmt.Fprintf(out, "comment %s\n", comment)
+	}
+	if movie.SaveState != nil {
+		fmt.Fprintf(out, "savestate base64:%s\n", base64.StdEncoding.EncodeToString(movie.SaveState))
+	}
+
+	for _, frame := range movie.Frames {
+		fmt.Fprintf(out, "|%d|", frame.Commands)
+		if movie.FourScore {
+			for player := 0; player < MaxPlayers; player++ {
+				fmt.Fprintf(out, "%s|", formatButtons(frame.Buttons[player]))
+			}
+		} else {
+			for player := 0; player < 2; player++ {
+				if movie.Ports[player] == DeviceGamepad {
+					out.WriteString(formatButtons(frame.Buttons[player]))
+				}
+				out.WriteString("|")
+			}
+		}
+		out.WriteString("|\n")
+	}
+
+	return out.Flush()
+}
+
+func boolToInt(value bool) int {
+	if value {
+		return 1
+	}
+
+	return 0
+}
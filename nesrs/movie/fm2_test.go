@@ -0,0 +1,92 @@
+package movie
+
+import (
+	"bytes"
+	"encoding/base64"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const testFM2 = `version 3
+emuVersion 22020
+rerecordCount 7
+palFlag 0
+romFilename smb
+romChecksum base64:jjYwGG411HcjG/j9UOVM3Q==
+guid 452DE2C3-EF43-2FA9-77AC-0677FC51543B
+fourscore 0
+port0 1
+port1 1
+port2 0
+comment author someone
+|0|........|........||
+|1|R......A|........||
+|0|.L..T.B.|...U....||
+`
+
+func TestReadFM2(t *testing.T) {
+	movie, err := Read(strings.NewReader(testFM2))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if movie.RerecordCount != 7 || movie.ROMFilename != "smb" || movie.FourScore || len(movie.Comments) != 1 {
+		t.Errorf("Wrong header %+v", movie)
+	}
+
+	want := []Frame{
+		{0, [MaxPlayers]int{0, 0}},
+		{CommandReset, [MaxPlayers]int{0x81, 0}},
+		{0, [MaxPlayers]int{0x40 | 0x08 | 0x02, 0x10}},
+	}
+	if !reflect.DeepEqual(movie.Frames, want) {
+		t.Errorf("Wrong frames %v, right %v", movie.Frames, want)
+	}
+}
+
+func TestWriteFM2(t *testing.T) {
+	movie := New()
+	movie.FourScore = true
+	movie.SaveState = []byte("NESRS\x1A\x01\x02\x03")
+	movie.Frames = []Frame{{0, [MaxPlayers]int{0x01, 0x02, 0x04, 0x80}}, {CommandReset, [MaxPlayers]int{}}}
+
+	var out bytes.Buffer
+	if err := movie.Write(&out); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out.String(), "|0|.......A|......B.|.....S..|R.......||\n") {
+		t.Errorf("Wrong frame line in\n%s", out.String())
+	}
+
+	read, err := Read(&out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(read, movie) {
+		t.Errorf("Wrong movie %+v, right %+v", read, movie)
+	}
+}
+
+func TestReadFM2Errors(t *testing.T) {
+	tests := []string{
+		"|0|........|........||\n", // No header
+		"version 3\nbinary 1\n",
+		"version 3\nport1 2\n",
+		"version 3\n|x|........|........||\n",
+		"version 3\n|0|....|........||\n",
+	}
+	for i, test := range tests {
+		if _, err := Read(strings.NewReader(test)); err == nil {
+			t.Errorf("Test %v: no error", i)
+		}
+	}
+
+	// FCEUX savestates
+	for _, saveState := range []string{"base64:" + base64.StdEncoding.EncodeToString([]byte("FCSX\x00\x00")), "0x46435358"} {
+		_, err := Read(strings.NewReader("version 3\nsavestate " + saveState + "\n"))
+		if err == nil || !strings.Contains(err.Error(), ErrSaveStateFormat.Error()) {
+			t.Errorf("Savestate %v: wrong error %v", saveState, err)
+		}
+	}
+}
@@ -0,0 +1,81 @@
+// Package movie holds per-frame controller input of a recorded session and reads/writes it in
+// FCEUX .fm2 text format.
+package movie
+
+import (
+	"crypto/md5"
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+)
+
+// Commands issued at the beginning of a frame (fm2 commands field).
+const (
+	CommandReset = 0x01 // Soft reset
+	CommandPower = 0x02 // Hard reset (not supported by playback)
+)
+
+// fm2 port devices.
+const (
+	DeviceNone    = 0
+	DeviceGamepad = 1
+	DeviceZapper  = 2
+)
+
+// MaxPlayers - with Four Score.
+const MaxPlayers = 4
+
+// Frame - input of one frame.
+type Frame struct {
+	Commands int
+	Buttons  [MaxPlayers]int // controller.Button* masks of each player
+}
+
+// Movie - input of every frame since power on or since SaveState was taken.
+type Movie struct {
+	EmuVersion    int
+	RerecordCount int
+	ROMFilename   string
+	ROMChecksum   string // "base64:" + MD5 of ROM data (see ROMChecksum)
+	GUID          string
+	PAL           bool
+	FourScore     bool
+	Ports         [3]int // Device* in port 1, port 2 and expansion port
+	Comments      []string
+	SaveState     []byte // nesrs save state the movie starts from (nil means power on)
+	Frames        []Frame
+}
+
+// New empty movie with a random GUID.
+func New() *Movie {
+	movie := Movie{
+		EmuVersion: emuVersion,
+		GUID:       newGUID(),
+		Ports:      [3]int{DeviceGamepad, DeviceGamepad, DeviceNone}}
+
+	return &movie
+}
+
+// ROMChecksum of iNES ROM image. Header and trainer aren't included.
+func ROMChecksum(rom []byte) string {
+	data := rom
+	if len(data) >= 16 {
+		hasTrainer := (data[6] & 0x04) != 0
+		data = data[16:]
+		if hasTrainer && len(data) >= 512 {
+			data = data[512:]
+		}
+	}
+
+	sum := md5.Sum(data)
+	return "base64:" + base64.StdEncoding.EncodeToString(sum[:])
+}
+
+func newGUID() string {
+	var uuid [16]byte
+	rand.Read(uuid[:])
+	uuid[6] = (uuid[6] & 0x0F) | 0x40 // Version 4
+	uuid[8] = (uuid[8] & 0x3F) | 0x80 // Variant
+
+	return fmt.Sprintf("%X-%X-%X-%X-%X", uuid[0:4], uuid[4:6], uuid[6:8], uuid[8:10], uuid[10:16])
+}
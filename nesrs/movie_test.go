@@ -0,0 +1,82 @@
+package nesrs
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/alpetkov/nesrs_go/nesrs/movie"
+)
+
+func runFrames(nes *NES, count int) {
+	for i := 0; i < count; i++ {
+		nes.runFrame()
+	}
+}
+
+func TestMoviePlayback(t *testing.T) {
+	for _, fromSaveState := range []bool{false, true} {
+		nes, _ := New(testROM(), nil, nil, &testInputProvider{})
+		if fromSaveState {
+			nes.Start()
+			runFrames(nes, 5)
+		}
+
+		recording, err := nes.RecordMovie()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !fromSaveState {
+			nes.Start()
+		}
+		runFrames(nes, 10)
+		nes.Reset()
+		runFrames(nes, 10)
+
+		var expected bytes.Buffer
+		nes.SaveState(&expected)
+		nes.StopMovie()
+
+		// Through fm2
+		var fm2 bytes.Buffer
+		if err := recording.Write(&fm2); err != nil {
+			t.Fatal(err)
+		}
+		recorded, err := movie.Read(&fm2)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(recorded.Frames) != 21 || recorded.Frames[11].Commands != movie.CommandReset {
+			t.Errorf("Wrong recorded frames %v", recorded.Frames)
+		}
+
+		player, _ := New(testROM(), nil, nil, nil)
+		if err := player.PlayMovie(recorded); err != nil {
+			t.Fatal(err)
+		}
+		runFrames(player, 20)
+		if !player.IsMoviePlaying() {
+			t.Errorf("Movie ended early")
+		}
+
+		var actual bytes.Buffer
+		player.SaveState(&actual)
+		if !bytes.Equal(expected.Bytes(), actual.Bytes()) {
+			t.Errorf("Movie desynced (from save state %v)", fromSaveState)
+		}
+
+		runFrames(player, 1)
+		if player.IsMoviePlaying() {
+			t.Errorf("Movie didn't end")
+		}
+	}
+}
+
+func TestMovieWrongROM(t *testing.T) {
+	recorded := movie.New()
+	recorded.ROMChecksum = "base64:AAAAAAAAAAAAAAAAAAAAAA=="
+
+	nes, _ := New(testROM(), nil, nil, nil)
+	if err := nes.PlayMovie(recorded); err != ErrMovieROM {
+		t.Errorf("Wrong error %v", err)
+	}
+}
@@ -2,9 +2,18 @@ package nesrs
 
 import (
 	"bytes"
+	"context"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"runtime"
+	"sync"
 
+	"github.com/alpetkov/nesrs_go/nesrs/apu"
 	"github.com/alpetkov/nesrs_go/nesrs/cartridge"
+	"github.com/alpetkov/nesrs_go/nesrs/controller"
 	"github.com/alpetkov/nesrs_go/nesrs/cpu"
+	"github.com/alpetkov/nesrs_go/nesrs/movie"
 	"github.com/alpetkov/nesrs_go/nesrs/ppu"
 )
 
@@ -14,11 +23,50 @@ const (
 	stopped
 )
 
-// NES The.
+// IRQ lines connected to the CPU.
+const (
+	irqMapper = 1 << iota
+	irqAPU
+)
+
+// Devices selectable for port 2 ($4017) at construction.
+const (
+	Port2Joypad = iota
+	Port2Zapper
+	Port2Vaus
+	Port2PowerPad
+)
+
+// NES The. Control methods can be called from any goroutine while Run is executing; they take
+// effect between frames. Receivers are called from the Run goroutine and must not call back
+// into the NES.
 type NES struct {
-	cpu   *cpu.CPU
-	ppu   *ppu.PPU
-	state int
+	lock           sync.Mutex // Guards everything below
+	stateChanged   *sync.Cond
+	cpu            *cpu.CPU
+	ppu            *ppu.PPU
+	apu            *apu.APU
+	cpuMemory      *cpu.NESCPUMemory
+	cartridge      *cartridge.Cartridge
+	input          *frameInput
+	videoReceiver  ppu.VideoReceiver
+	audioReceiver  apu.AudioReceiver
+	state          int
+	frame          int
+	sramWriter     io.Writer
+	romCRC         uint32
+	romChecksum    string
+	rewind         *rewindBuffer
+	isReplaying    bool
+	isSnapshotDue  bool
+	isResetDue     bool
+	isFourScore    bool
+	movie          *movie.Movie
+	movieMode      int
+	movieStart     int
+	pacing         pacing
+	isPacingReset  bool
+	isVideoSkipped bool
 }
 
 // CPUVBLReceiver .
@@ -31,52 +79,511 @@ func (vblReceiver *CPUVBLReceiver) ReceiveVBL() {
 	vblReceiver.cpu.NMI()
 }
 
-// New NES.
-func New(rom []byte, videoReceiver ppu.VideoReceiver) *NES {
+// CPUIRQReceiver .
+type CPUIRQReceiver struct {
+	cpu    *cpu.CPU
+	source int
+}
+
+// ReceiveIRQ .
+func (irqReceiver *CPUIRQReceiver) ReceiveIRQ(asserted bool) {
+	irqReceiver.cpu.SetIRQLine(irqReceiver.source, asserted)
+}
+
+// CPUDMAReceiver .
+type CPUDMAReceiver struct {
+	cpu *cpu.CPU
+}
+
+// ReceiveDMA .
+func (dmaReceiver *CPUDMAReceiver) ReceiveDMA(cpuCycles int) {
+	dmaReceiver.cpu.Stall(cpuCycles)
+}
+
+// frameReceiver forwards PPU frames and handles frame boundaries.
+type frameReceiver struct {
+	nes *NES
+}
+
+// ReceiveFrame .
+func (frameReceiver *frameReceiver) ReceiveFrame(frame []int) {
+	nes := frameReceiver.nes
+	if !nes.isReplaying && !nes.isVideoSkipped {
+		nes.sendFrame(frame, nes.frame)
+	}
+
+	frameReceiver.nes.endFrame()
+}
+
+// ReceiveIndexedFrame .
+func (frameReceiver *frameReceiver) ReceiveIndexedFrame(frame []int) {
+	nes := frameReceiver.nes
+	indexedVideoReceiver, ok := nes.videoReceiver.(ppu.IndexedVideoReceiver)
+	if ok && !nes.isReplaying && !nes.isVideoSkipped {
+		indexedVideoReceiver.ReceiveIndexedFrame(frame)
+	}
+}
+
+// sendFrame to the video receiver, with its number when the receiver wants it.
+func (nes *NES) sendFrame(frame []int, number int) {
+	if numberedVideoReceiver, ok := nes.videoReceiver.(ppu.NumberedVideoReceiver); ok {
+		numberedVideoReceiver.ReceiveNumberedFrame(frame, number)
+	} else if nes.videoReceiver != nil {
+		nes.videoReceiver.ReceiveFrame(frame)
+	}
+}
+
+// New NES. audioReceiver is optional (nil disables audio output). videoReceiver can also
+// implement ppu.IndexedVideoReceiver to get indexed frames and ppu.NumberedVideoReceiver to get
+// frame numbers.
+// inputProvider feeds standard controllers in both ports and is sampled once per frame.
+func New(rom []byte, videoReceiver ppu.VideoReceiver, audioReceiver apu.AudioReceiver,
+	inputProvider controller.InputProvider) (*NES, error) {
+
+	return NewWithPort2Device(rom, videoReceiver, audioReceiver, inputProvider, Port2Joypad)
+}
+
+// NewWithPort2Device NES with one of Port2* devices connected to port 2. The connected device
+// (e.g. *controller.Vaus) is available through Controller(2).
+func NewWithPort2Device(rom []byte, videoReceiver ppu.VideoReceiver, audioReceiver apu.AudioReceiver,
+	inputProvider controller.InputProvider, port2Device int) (*NES, error) {
+
 	// Assemble cpu
 	cpuMemory := cpu.NESCPUMemory{}
 	cpu := cpu.New(&cpuMemory)
 
 	// Assemble cartridge
-	cartridge := cartridge.New(bytes.NewReader(rom))
+	cartridge, err := cartridge.New(bytes.NewReader(rom))
+	if err != nil {
+		return nil, err
+	}
+	cartridge.SetIRQReceiver(&CPUIRQReceiver{cpu, irqMapper})
 
 	// Assemble ppu
 	vblReceiver := CPUVBLReceiver{cpu}
-	ppu := ppu.New(cartridge, &vblReceiver, videoReceiver)
+	frameReceiver := frameReceiver{}
+	ppu := ppu.New(cartridge, &vblReceiver, &frameReceiver)
+
+	// Assemble apu
+	apu := apu.New(&cpuMemory, &CPUIRQReceiver{cpu, irqAPU}, &CPUDMAReceiver{cpu}, audioReceiver)
+
+	// Assemble controllers
+	input := &frameInput{provider: inputProvider, players: 2}
 
 	// Memory-mapped devices
 	cpuMemory.SetCartridge(cartridge)
 	cpuMemory.SetPPU(ppu)
+	cpuMemory.SetAPU(apu)
+	cpuMemory.SetController1(controller.NewJoypad(input, 0))
+	switch port2Device {
+	case Port2Joypad:
+		cpuMemory.SetController2(controller.NewJoypad(input, 1))
+	case Port2Zapper:
+		cpuMemory.SetController2(controller.NewZapper(ppu))
+	case Port2Vaus:
+		cpuMemory.SetController2(controller.NewVaus())
+	case Port2PowerPad:
+		cpuMemory.SetController2(controller.NewPowerPad(input, 1))
+	default:
+		return nil, fmt.Errorf("nesrs: unknown port 2 device %d", port2Device)
+	}
 
-	nes := NES{cpu, ppu, stopped}
+	nes := NES{
+		cpu:           cpu,
+		ppu:           ppu,
+		apu:           apu,
+		cpuMemory:     &cpuMemory,
+		cartridge:     cartridge,
+		input:         input,
+		videoReceiver: videoReceiver,
+		audioReceiver: audioReceiver,
+		state:         stopped,
+		pacing:        pacing{speed: 1, maxFrameSkip: defaultMaxFrameSkip},
+		romCRC:        crc32.ChecksumIEEE(rom),
+		romChecksum:   movie.ROMChecksum(rom)}
+	nes.stateChanged = sync.NewCond(&nes.lock)
+	frameReceiver.nes = &nes
 
-	return &nes
+	return &nes, nil
 }
 
-// Start NES.
+// Start (power on) NES.
 func (nes *NES) Start() {
+	nes.lock.Lock()
+	defer nes.lock.Unlock()
+
+	nes.start()
+}
+
+func (nes *NES) start() {
 	nes.cpu.Init()
 	nes.ppu.Init()
-	nes.state = started
+	nes.apu.Init()
+	nes.frame = 0
+	nes.isSnapshotDue = false
+	nes.isResetDue = false
+	nes.input.isResetPending = false
+	nes.startMovie()
+	if nes.rewind != nil {
+		nes.rewind.clear()
+	}
+	nes.beginFrame()
+	if nes.rewind != nil {
+		nes.takeSnapshot()
+	}
+	nes.setState(started)
 }
 
-// Reset NES.
+// Reset NES. Takes effect before the next op. While input is recorded (movie, rewind) it's
+// delayed to the end of the current frame, so the recording replays it at the same point.
 func (nes *NES) Reset() {
+	nes.lock.Lock()
+	defer nes.lock.Unlock()
+
+	if nes.movieMode == movieRecording || nes.rewind != nil {
+		nes.input.isResetPending = true
+		return
+	}
+
+	nes.reset()
+}
+
+// Pause running NES. Run waits until Resume or Stop.
+func (nes *NES) Pause() {
+	nes.lock.Lock()
+	defer nes.lock.Unlock()
+
+	if nes.state == started {
+		nes.setState(paused)
+	}
+}
+
+// Resume paused NES.
+func (nes *NES) Resume() {
+	nes.lock.Lock()
+	defer nes.lock.Unlock()
+
+	if nes.state == paused {
+		nes.setState(started)
+	}
+}
+
+// IsPaused .
+func (nes *NES) IsPaused() bool {
+	nes.lock.Lock()
+	defer nes.lock.Unlock()
+
+	return nes.state == paused
+}
+
+func (nes *NES) setState(state int) {
+	nes.state = state
+	nes.stateChanged.Broadcast()
+}
+
+func (nes *NES) reset() {
 	nes.cpu.Reset()
 	nes.ppu.Reset()
+	nes.apu.Reset()
+}
+
+// Stop NES. Run returns after the current frame. Buffered audio is sent to the audio receiver
+// and battery-backed RAM is flushed to the SRAM writer (if set).
+func (nes *NES) Stop() error {
+	nes.lock.Lock()
+	defer nes.lock.Unlock()
+
+	nes.setState(stopped)
+	nes.apu.Flush()
+
+	if nes.sramWriter == nil || !nes.cartridge.IsBatteryBacked() {
+		return nil
+	}
+
+	// Overwrite previous flush when possible (e.g. *os.File).
+	if seeker, ok := nes.sramWriter.(io.Seeker); ok {
+		if _, err := seeker.Seek(0, io.SeekStart); err != nil {
+			return err
+		}
+	}
+
+	return nes.cartridge.WriteSRAM(nes.sramWriter)
+}
+
+// LoadSRAM restores battery-backed RAM (e.g. from .sav file).
+func (nes *NES) LoadSRAM(reader io.Reader) error {
+	nes.lock.Lock()
+	defer nes.lock.Unlock()
+
+	return nes.cartridge.ReadSRAM(reader)
+}
+
+// SaveSRAM writes battery-backed RAM (e.g. to .sav file).
+func (nes *NES) SaveSRAM(writer io.Writer) error {
+	nes.lock.Lock()
+	defer nes.lock.Unlock()
+
+	return nes.cartridge.WriteSRAM(writer)
+}
+
+// IsBatteryBacked returns true when the cartridge has battery-backed RAM.
+func (nes *NES) IsBatteryBacked() bool {
+	return nes.cartridge.IsBatteryBacked()
 }
 
-// Stop NES.
-func (nes *NES) Stop() {
-	nes.state = stopped
+// SetSRAMWriter sets where battery-backed RAM is flushed on Stop.
+func (nes *NES) SetSRAMWriter(writer io.Writer) {
+	nes.lock.Lock()
+	defer nes.lock.Unlock()
+
+	nes.sramWriter = writer
 }
 
-// Run NES.
+// Run NES until Stop.
 func (nes *NES) Run() {
-	for nes.state == started {
-		cpuCycles := nes.cpu.ExecuteOp()
+	nes.RunContext(context.Background())
+}
+
+// RunContext runs NES until Stop or until the context is done (returning its error).
+// Frames are paced in real time (see SetSpeed). While paused it waits without consuming CPU.
+func (nes *NES) RunContext(ctx context.Context) error {
+	// Wake up paused loop when the context is done
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-ctx.Done():
+			nes.lock.Lock()
+			nes.stateChanged.Broadcast()
+			nes.lock.Unlock()
+		case <-done:
+		}
+	}()
+
+	nes.lock.Lock()
+	defer nes.lock.Unlock()
+	defer func() { nes.isVideoSkipped = false }()
+
+	pacer := pacer{}
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		switch nes.state {
+		case stopped:
+			return nil
+		case paused:
+			nes.stateChanged.Wait()
+			pacer.reset()
+		default:
+			nes.runFrame()
+
+			if nes.isPacingReset {
+				nes.isPacingReset = false
+				pacer.reset()
+			}
+			pacing := nes.pacing
+
+			// Let control methods in between frames
+			nes.lock.Unlock()
+			isVideoSkipped := pacer.pace(pacing, nes.audioReceiver)
+			runtime.Gosched()
+			nes.lock.Lock()
+
+			nes.isVideoSkipped = isVideoSkipped
+		}
+	}
+}
+
+// RunFrame runs until the PPU completes the current frame.
+func (nes *NES) RunFrame() {
+	nes.lock.Lock()
+	defer nes.lock.Unlock()
+
+	nes.runFrame()
+}
+
+// RunCycles runs at least the given number of CPU cycles (the last op isn't split).
+// Returns the number of cycles run.
+func (nes *NES) RunCycles(cpuCycles int) int {
+	nes.lock.Lock()
+	defer nes.lock.Unlock()
+
+	cycles := 0
+	for cycles < cpuCycles {
+		cycles += nes.executeOp()
+	}
+
+	return cycles
+}
+
+// RunUntil runs until the condition (checked after each op) is met.
+func (nes *NES) RunUntil(condition func(nes *NES) bool) {
+	for !condition(nes) {
+		nes.lock.Lock()
+		nes.executeOp()
+		nes.lock.Unlock()
+	}
+}
+
+// Frame - number of frames completed since Start.
+func (nes *NES) Frame() int {
+	nes.lock.Lock()
+	defer nes.lock.Unlock()
+
+	return nes.frame
+}
+
+// Peek reads CPU memory (RAM, cartridge RAM/ROM) without side effects.
+func (nes *NES) Peek(address int) int {
+	nes.lock.Lock()
+	defer nes.lock.Unlock()
+
+	return nes.cpuMemory.Peek(address)
+}
+
+// executeOp runs one CPU op and the PPU/APU for the same time.
+func (nes *NES) executeOp() int {
+	cpuCycles := nes.cpu.ExecuteOp()
+
+	ppuCycles := cpuCycles * 3
+	nes.ppu.ExecuteCycles(ppuCycles)
+
+	nes.apu.ExecuteCycles(cpuCycles)
+
+	// Reset and snapshot once the whole machine is at the op boundary
+	if nes.isResetDue {
+		nes.isResetDue = false
+		nes.reset()
+	}
+	if nes.isSnapshotDue {
+		nes.isSnapshotDue = false
+		if nes.rewind != nil {
+			nes.takeSnapshot()
+		}
+	}
+
+	return cpuCycles
+}
+
+// runFrame runs until the current frame ends.
+func (nes *NES) runFrame() {
+	frame := nes.frame
+	for nes.frame == frame {
+		nes.executeOp()
+	}
+}
+
+// endFrame is called once the PPU has rendered the last visible scanline.
+func (nes *NES) endFrame() {
+	nes.frame++
+	nes.apu.Flush()
+	nes.beginFrame()
+
+	if nes.rewind != nil && !nes.isReplaying {
+		nes.isSnapshotDue = nes.frame%nes.rewind.interval == 0
+	}
+}
+
+// beginFrame sets input of the frame from the rewind history, the movie being played or
+// the input provider, and records it.
+func (nes *NES) beginFrame() {
+	input := nes.nextInput()
+	nes.input.buttons = input.Buttons
+	if input.Commands&movie.CommandReset != 0 {
+		nes.isResetDue = true
+	}
 
-		ppuCycles := cpuCycles * 3
-		nes.ppu.ExecuteCycles(ppuCycles)
+	if nes.isReplaying {
+		return
 	}
+	if nes.rewind != nil {
+		nes.rewind.recordInput(input)
+	}
+	nes.recordMovieInput(input)
+}
+
+func (nes *NES) nextInput() movie.Frame {
+	if nes.isReplaying {
+		return nes.rewind.input(nes.frame)
+	}
+	if input, ok := nes.movieInput(); ok {
+		return input
+	}
+
+	return nes.input.poll()
+}
+
+// ConnectController connects the device to port 1 ($4016) or port 2 ($4017). Rewind history
+// before the change is dropped.
+func (nes *NES) ConnectController(port int, device controller.Device) {
+	nes.lock.Lock()
+	defer nes.lock.Unlock()
+
+	nes.connectController(port, device)
+}
+
+func (nes *NES) connectController(port int, device controller.Device) {
+	switch port {
+	case 1:
+		nes.cpuMemory.SetController1(device)
+	case 2:
+		nes.cpuMemory.SetController2(device)
+	}
+
+	// Older snapshots have state of the previous device
+	if nes.rewind != nil {
+		nes.rewind.clear()
+		nes.takeSnapshot()
+	}
+}
+
+// Controller connected to port 1 or port 2 (nil if none).
+func (nes *NES) Controller(port int) controller.Device {
+	nes.lock.Lock()
+	defer nes.lock.Unlock()
+
+	switch port {
+	case 1:
+		return nes.cpuMemory.Controller1()
+	case 2:
+		return nes.cpuMemory.Controller2()
+	}
+
+	return nil
+}
+
+// SetFourScore connects the Four Score adapter (four players) to both ports, or standard
+// controllers when disabled.
+func (nes *NES) SetFourScore(enabled bool) {
+	nes.lock.Lock()
+	defer nes.lock.Unlock()
+
+	nes.setFourScore(enabled)
+}
+
+func (nes *NES) setFourScore(enabled bool) {
+	nes.isFourScore = enabled
+	if enabled {
+		nes.input.players = maxPlayers
+		port1, port2 := controller.NewFourScore(nes.input)
+		nes.connectController(1, port1)
+		nes.connectController(2, port2)
+	} else {
+		nes.input.players = 2
+		nes.connectController(1, controller.NewJoypad(nes.input, 0))
+		nes.connectController(2, controller.NewJoypad(nes.input, 1))
+	}
+}
+
+// ConnectZapper connects a light gun to port 2. Aim and trigger are set through the returned Zapper.
+func (nes *NES) ConnectZapper() *controller.Zapper {
+	nes.lock.Lock()
+	defer nes.lock.Unlock()
+
+	zapper := controller.NewZapper(nes.ppu)
+	nes.connectController(2, zapper)
+
+	return zapper
 }
@@ -0,0 +1,130 @@
+package nesrs
+
+import (
+	"errors"
+	"time"
+
+	"github.com/alpetkov/nesrs_go/nesrs/apu"
+)
+
+// FrameRate - NTSC frames per second.
+const FrameRate = 60.0988
+
+// Speed multipliers.
+const (
+	Unthrottled = 0
+	MinSpeed    = 0.25
+	MaxSpeed    = 8
+)
+
+// Pacing limits.
+const (
+	defaultMaxFrameSkip = 4
+	maxPacingLag        = 100 * time.Millisecond // Further behind is not caught up with
+	audioSyncLatency    = 3                      // Frames of audio queued ahead
+)
+
+// ErrSpeed is returned for speed out of MinSpeed - MaxSpeed range.
+var ErrSpeed = errors.New("nesrs: speed out of range")
+
+// pacing settings.
+type pacing struct {
+	speed        float64 // Multiplier of FrameRate, Unthrottled runs as fast as possible
+	isAudioSync  bool
+	maxFrameSkip int
+}
+
+// pacer keeps Run at the frame rate. Only used by the Run goroutine.
+type pacer struct {
+	start   time.Time
+	frames  int
+	skipped int
+}
+
+func (pacer *pacer) reset() {
+	pacer.start = time.Time{}
+	pacer.frames = 0
+	pacer.skipped = 0
+}
+
+// pace waits until the next frame is due. Returns true when the next frame should be skipped
+// by the video receiver to catch up.
+func (pacer *pacer) pace(pacing pacing, audioReceiver apu.AudioReceiver) bool {
+	if pacing.speed == Unthrottled {
+		return false
+	}
+
+	// Audio device clock drives emulation at normal speed
+	audioBuffer, ok := audioReceiver.(apu.AudioBuffer)
+	if pacing.isAudioSync && pacing.speed == 1 && ok {
+		pacer.reset()
+		latency := int(audioSyncLatency * float64(audioReceiver.SampleRate()) / FrameRate)
+		deadline := time.Now().Add(maxPacingLag)
+		for audioBuffer.BufferedSamples() > latency && time.Now().Before(deadline) {
+			time.Sleep(time.Millisecond)
+		}
+		return false
+	}
+
+	now := time.Now()
+	if pacer.start.IsZero() {
+		pacer.start = now
+	}
+	pacer.frames++
+
+	frameDuration := time.Duration(float64(time.Second) / (FrameRate * pacing.speed))
+	due := pacer.start.Add(time.Duration(pacer.frames) * frameDuration)
+	if wait := due.Sub(now); wait > 0 {
+		time.Sleep(wait)
+		pacer.skipped = 0
+		return false
+	}
+
+	lag := now.Sub(due)
+	if lag > maxPacingLag {
+		// Host can't keep up (or was suspended), continue from now
+		pacer.start = now
+		pacer.frames = 0
+	}
+	if lag > frameDuration && pacer.skipped < pacing.maxFrameSkip {
+		pacer.skipped++
+		return true
+	}
+
+	pacer.skipped = 0
+	return false
+}
+
+// SetSpeed of Run as multiplier of FrameRate (MinSpeed - MaxSpeed), or Unthrottled.
+func (nes *NES) SetSpeed(speed float64) error {
+	if speed != Unthrottled && (speed < MinSpeed || speed > MaxSpeed) {
+		return ErrSpeed
+	}
+
+	nes.lock.Lock()
+	defer nes.lock.Unlock()
+
+	nes.pacing.speed = speed
+	nes.isPacingReset = true
+
+	return nil
+}
+
+// SetMaxFrameSkip - maximum number of consecutive frames not sent to the video receiver
+// when Run is behind. Zero sends every frame.
+func (nes *NES) SetMaxFrameSkip(frames int) {
+	nes.lock.Lock()
+	defer nes.lock.Unlock()
+
+	nes.pacing.maxFrameSkip = frames
+}
+
+// SetAudioSync paces Run at normal speed by the audio device instead of the system clock, which
+// avoids audio buffer underruns and overruns. Requires audio receiver implementing apu.AudioBuffer.
+func (nes *NES) SetAudioSync(enabled bool) {
+	nes.lock.Lock()
+	defer nes.lock.Unlock()
+
+	nes.pacing.isAudioSync = enabled
+	nes.isPacingReset = true
+}
@@ -0,0 +1,51 @@
+package nesrs
+
+import (
+	"testing"
+	"time"
+)
+
+func TestPacer(t *testing.T) {
+	pacer := pacer{}
+	pacing := pacing{speed: 4, maxFrameSkip: 2}
+	frameDuration := time.Duration(float64(time.Second) / (FrameRate * pacing.speed))
+
+	start := time.Now()
+	for i := 0; i < 12; i++ {
+		if pacer.pace(pacing, nil) {
+			t.Errorf("Frame %v skipped", i)
+		}
+	}
+	if elapsed := time.Since(start); elapsed < 12*frameDuration {
+		t.Errorf("Too fast %v", elapsed)
+	}
+
+	// Behind by about 3 frames
+	pacer.reset()
+	pacing.speed = 1
+	frameDuration *= 4
+	pacer.start = time.Now().Add(-4 * frameDuration)
+	skipped := 0
+	for i := 0; i < 4; i++ {
+		if pacer.pace(pacing, nil) {
+			skipped++
+		}
+	}
+	if skipped != pacing.maxFrameSkip {
+		t.Errorf("Wrong skipped frames %v", skipped)
+	}
+}
+
+func TestSetSpeed(t *testing.T) {
+	nes, _ := New(testROM(), nil, nil, nil)
+	for _, speed := range []float64{Unthrottled, MinSpeed, 1, MaxSpeed} {
+		if err := nes.SetSpeed(speed); err != nil {
+			t.Errorf("Speed %v: %v", speed, err)
+		}
+	}
+	for _, speed := range []float64{0.1, 9, -1} {
+		if err := nes.SetSpeed(speed); err != ErrSpeed {
+			t.Errorf("Speed %v: %v", speed, err)
+		}
+	}
+}
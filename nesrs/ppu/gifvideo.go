@@ -0,0 +1,108 @@
+package ppu
+
+import (
+	"errors"
+	"image"
+	"image/color"
+	"image/gif"
+	"io"
+	"time"
+)
+
+// Default GIFVideoReceiver step: every 3rd frame, ~20 fps. Browsers don't honor GIF delays
+// shorter than 2/100 s, so recording every frame doesn't work.
+const defaultGIFStep = 3
+
+// ErrNoGIFFrames - nothing was recorded.
+var ErrNoGIFFrames = errors.New("ppu: no frames recorded for GIF")
+
+// GIFVideoReceiver records a range of frames in memory as an animated GIF with the NES palette.
+// Frames are counted from the first frame received.
+type GIFVideoReceiver struct {
+	First int // First frame to record
+	Count int // Number of frames to record (0 - until Write)
+	Step  int // Record every Step-th frame (3 if not set)
+
+	frame   int
+	images  []*image.Paletted
+	delays  []int
+	palette color.Palette
+	indexes map[int]uint8 // RGB -> palette index
+}
+
+// ReceiveFrame .
+func (gifVideoReceiver *GIFVideoReceiver) ReceiveFrame(frame []int) {
+	number := gifVideoReceiver.frame - gifVideoReceiver.First
+	gifVideoReceiver.frame++
+
+	if number < 0 || (gifVideoReceiver.Count > 0 && number >= gifVideoReceiver.Count) {
+		return
+	}
+	step := gifVideoReceiver.step()
+	if number%step != 0 {
+		return
+	}
+
+	if gifVideoReceiver.palette == nil {
+		gifVideoReceiver.initPalette()
+	}
+
+	img := image.NewPaletted(image.Rect(0, 0, NESWidth, NESHeight), gifVideoReceiver.palette)
+	for i, rgb := range frame {
+		index, ok := gifVideoReceiver.indexes[rgb]
+		if !ok {
+			index = uint8(gifVideoReceiver.palette.Index(color.RGBA{
+				R: uint8(rgb >> 16), G: uint8(rgb >> 8), B: uint8(rgb), A: 0xFF}))
+			gifVideoReceiver.indexes[rgb] = index
+		}
+		img.Pix[i] = index
+	}
+
+	// Delays in 1/100 s, rounded so they don't drift from the NTSC frame rate
+	recorded := len(gifVideoReceiver.images)
+	delay := centiseconds((recorded+1)*step) - centiseconds(recorded*step)
+
+	gifVideoReceiver.images = append(gifVideoReceiver.images, img)
+	gifVideoReceiver.delays = append(gifVideoReceiver.delays, delay)
+}
+
+// Frames - number of frames recorded.
+func (gifVideoReceiver *GIFVideoReceiver) Frames() int {
+	return len(gifVideoReceiver.images)
+}
+
+// Write .
+func (gifVideoReceiver *GIFVideoReceiver) Write(out io.Writer) error {
+	if len(gifVideoReceiver.images) == 0 {
+		return ErrNoGIFFrames
+	}
+
+	return gif.EncodeAll(out, &gif.GIF{
+		Image: gifVideoReceiver.images,
+		Delay: gifVideoReceiver.delays})
+}
+
+func (gifVideoReceiver *GIFVideoReceiver) step() int {
+	if gifVideoReceiver.Step <= 0 {
+		return defaultGIFStep
+	}
+
+	return gifVideoReceiver.Step
+}
+
+func (gifVideoReceiver *GIFVideoReceiver) initPalette() {
+	gifVideoReceiver.palette = make(color.Palette, len(paletteRGB))
+	gifVideoReceiver.indexes = make(map[int]uint8, len(paletteRGB))
+	for i, rgb := range paletteRGB {
+		gifVideoReceiver.palette[i] = color.RGBA{
+			R: uint8(rgb >> 16), G: uint8(rgb >> 8), B: uint8(rgb), A: 0xFF}
+		if _, ok := gifVideoReceiver.indexes[rgb]; !ok {
+			gifVideoReceiver.indexes[rgb] = uint8(i)
+		}
+	}
+}
+
+// centiseconds since the first frame at the NTSC frame rate.
+func centiseconds(frames int) int {
+	return int((FrameTime(frames) + 5*time.Millisecond) / (10 * time.Millisecond))
+}
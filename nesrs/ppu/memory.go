@@ -7,6 +7,7 @@ type vramMemory struct {
 	backgroundPaletteRAM [16]int // Background Palette RAM (16b)
 	spritePaletteRAM     [16]int // Sprite Palette RAM (16b)
 	cartridge            *cartridge.Cartridge
+	ppuCycle             int // PPU cycles since power on (wrapped by cartridge.PPUCycleMask)
 }
 
 type spriteMemory struct {
@@ -29,6 +30,7 @@ func (vramMemory *vramMemory) read(address int) int {
 
 	if 0 <= decodedAddress && decodedAddress <= 0x1FFF {
 		// CHR ROM/RAM
+		vramMemory.cartridge.ObservePPUAddress(decodedAddress, vramMemory.ppuCycle)
 		return vramMemory.cartridge.ReadChrMemory(decodedAddress)
 
 	} else if 0x2000 <= decodedAddress && decodedAddress < 0x3000 {
@@ -53,6 +55,7 @@ func (vramMemory *vramMemory) write(address int, value int) {
 
 	if 0 <= decodedAddress && decodedAddress <= 0x1FFF {
 		// CHR ROM/RAM
+		vramMemory.cartridge.ObservePPUAddress(decodedAddress, vramMemory.ppuCycle)
 		vramMemory.cartridge.WriteChrMemory(decodedAddress, value)
 
 	} else if 0x2000 <= decodedAddress && decodedAddress <= 0x2FFF {
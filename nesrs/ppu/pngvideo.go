@@ -0,0 +1,40 @@
+package ppu
+
+import (
+	"image"
+	"image/png"
+	"io"
+)
+
+// PNGVideoReceiver keeps the last frame and writes it as PNG.
+type PNGVideoReceiver struct {
+	frame [NESWidth * NESHeight]int
+}
+
+// ReceiveFrame .
+func (pngVideoReceiver *PNGVideoReceiver) ReceiveFrame(frame []int) {
+	copy(pngVideoReceiver.frame[:], frame)
+}
+
+// Image of the last frame.
+func (pngVideoReceiver *PNGVideoReceiver) Image() *image.RGBA {
+	return FrameImage(pngVideoReceiver.frame[:])
+}
+
+// Write .
+func (pngVideoReceiver *PNGVideoReceiver) Write(out io.Writer) error {
+	return png.Encode(out, pngVideoReceiver.Image())
+}
+
+// FrameImage converts a frame (0xRRGGBB pixels) to an image.
+func FrameImage(frame []int) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, NESWidth, NESHeight))
+	for i, rgb := range frame {
+		img.Pix[i*4] = uint8(rgb >> 16)
+		img.Pix[i*4+1] = uint8(rgb >> 8)
+		img.Pix[i*4+2] = uint8(rgb)
+		img.Pix[i*4+3] = 0xFF
+	}
+
+	return img
+}
@@ -1,6 +1,8 @@
 package ppu
 
 import (
+	"time"
+
 	"github.com/alpetkov/nesrs_go/nesrs/cartridge"
 )
 
@@ -10,11 +12,40 @@ const (
 	NESWidth  = 256
 )
 
+// NTSC frame rate (~60.0988 fps) as an exact fraction: 39375000 / 655171.
+const (
+	FrameRateNumerator   = 39375000
+	FrameRateDenominator = 655171
+)
+
+// FrameTime - presentation time of the frame (counted from 0) at the NTSC frame rate.
+func FrameTime(frame int) time.Duration {
+	ticks := int64(frame) * FrameRateDenominator
+	seconds := ticks / FrameRateNumerator
+	remainder := ticks % FrameRateNumerator
+
+	return time.Duration(seconds)*time.Second + time.Duration(remainder*int64(time.Second)/FrameRateNumerator)
+}
+
 // VideoReceiver - handles PPU frames.
 type VideoReceiver interface {
 	ReceiveFrame(frame []int)
 }
 
+// IndexedVideoReceiver - handles PPU frames as 9-bit pixels: palette color index (bits 0-5) and
+// the color emphasis bits of the mask register (bits 6-8). A VideoReceiver implementing it gets
+// the indexed frame right before the RGB one.
+type IndexedVideoReceiver interface {
+	ReceiveIndexedFrame(frame []int)
+}
+
+// NumberedVideoReceiver - handles frames along their number since power on, which keeps counting
+// frames that weren't sent (e.g. skipped to keep up with real time). A VideoReceiver implementing
+// it gets frames through ReceiveNumberedFrame instead of ReceiveFrame.
+type NumberedVideoReceiver interface {
+	ReceiveNumberedFrame(frame []int, number int)
+}
+
 // VBLReceiver - handles PPU VBlank signals.
 type VBLReceiver interface {
 	ReceiveVBL()
@@ -33,6 +64,7 @@ type PPU struct {
 	spriteRenderer             *spriteRenderer            //
 	scanlineOffscreenBuffer    [NESWidth]int              // Pixel buffers
 	frameBuffer                [NESHeight * NESWidth]int  //
+	indexedFrameBuffer         [NESHeight * NESWidth]int  //
 	currentCycle               int                        // Counters
 	currentScanline            int                        //
 	currentScanlineCyclesCount int                        //
@@ -40,6 +72,7 @@ type PPU struct {
 	canSetVblForFrame          bool                       //
 	vblReceiver                VBLReceiver                // Receivers
 	videoReceiver              VideoReceiver              //
+	indexedVideoReceiver       IndexedVideoReceiver       //
 }
 
 // New instance of PPU.
@@ -56,6 +89,8 @@ func New(cartridge *cartridge.Cartridge, vblReceiver VBLReceiver, videoReceiver
 	backgroundRenderer := newBackgroundRenderer(ctrlReg, maskReg, vramAddressScrollReg, vramMemory)
 	spriteRenderer := newSpriteRenderer(ctrlReg, maskReg, statusReg, vramMemory, spriteMemory)
 
+	indexedVideoReceiver, _ := videoReceiver.(IndexedVideoReceiver)
+
 	ppu := PPU{
 		ctrlReg:              ctrlReg,
 		maskReg:              maskReg,
@@ -67,7 +102,8 @@ func New(cartridge *cartridge.Cartridge, vblReceiver VBLReceiver, videoReceiver
 		backgroundRenderer:   backgroundRenderer,
 		spriteRenderer:       spriteRenderer,
 		vblReceiver:          vblReceiver,
-		videoReceiver:        videoReceiver}
+		videoReceiver:        videoReceiver,
+		indexedVideoReceiver: indexedVideoReceiver}
 
 	return &ppu
 }
@@ -114,6 +150,7 @@ func (ppu *PPU) Reset() {
 func (ppu *PPU) ExecuteCycles(ppuCycles int) {
 	for i := 0; i < ppuCycles; i++ {
 		ppu.currentCycle++
+		ppu.vramMemory.ppuCycle = (ppu.vramMemory.ppuCycle + 1) & cartridge.PPUCycleMask
 
 		//
 		// Determine scanline & frame
@@ -131,6 +168,7 @@ func (ppu *PPU) ExecuteCycles(ppuCycles int) {
 				ppu.isOddFrame = !ppu.isOddFrame
 				for i := range ppu.frameBuffer {
 					ppu.frameBuffer[i] = 0x00
+					ppu.indexedFrameBuffer[i] = 0x00
 				}
 			}
 		}
@@ -238,12 +276,14 @@ func (ppu *PPU) ExecuteCycles(ppuCycles int) {
 
 			// Send to video
 			if ppu.currentCycle == ppu.currentScanlineCyclesCount-1 {
-				scanlineBuffer := ppu.getScanlineVideo()
 				offset := (ppu.currentScanline - FirstRenderScanline) * NESWidth
-				for i := range scanlineBuffer {
-					ppu.frameBuffer[offset+i] = scanlineBuffer[i]
-				}
+				ppu.renderScanlineVideo(
+					ppu.frameBuffer[offset:offset+NESWidth],
+					ppu.indexedFrameBuffer[offset:offset+NESWidth])
 				if ppu.currentScanline == LastRenderScanline {
+					if ppu.indexedVideoReceiver != nil {
+						ppu.indexedVideoReceiver.ReceiveIndexedFrame(ppu.indexedFrameBuffer[:])
+					}
 					ppu.videoReceiver.ReceiveFrame(ppu.frameBuffer[:])
 				}
 			}
@@ -393,6 +433,44 @@ func (ppu *PPU) WriteRegister(register int, value int) {
 	}
 }
 
+// Frame returns the frame buffer. It holds a complete frame from the end of the last render
+// scanline until the next frame starts.
+func (ppu *PPU) Frame() []int {
+	return ppu.frameBuffer[:]
+}
+
+// IndexedFrame returns the indexed frame buffer (see IndexedVideoReceiver).
+func (ppu *PPU) IndexedFrame() []int {
+	return ppu.indexedFrameBuffer[:]
+}
+
+// IndexedToRGB converts an indexed pixel to RGB with the default palette. Emphasis bits are
+// ignored, as in RGB frames.
+func IndexedToRGB(pixel int) int {
+	return paletteRGB[pixel&0x3F]
+}
+
+// Pixel returns RGB of the pixel at (x, y) in the frame being rendered. Pixels are available once
+// their scanline is complete; the rest (and anything outside the screen) is black.
+func (ppu *PPU) Pixel(x int, y int) int {
+	if x < 0 || x >= NESWidth || y < 0 || y >= NESHeight {
+		return 0
+	}
+
+	return ppu.frameBuffer[y*NESWidth+x]
+}
+
+// BeamPosition returns screen coordinates currently being rendered. y is outside [0, NESHeight)
+// during vertical blank.
+func (ppu *PPU) BeamPosition() (int, int) {
+	y := ppu.currentScanline - FirstRenderScanline
+	if y < 0 {
+		y += ScanlineCountInFrame
+	}
+
+	return ppu.currentCycle, y
+}
+
 func (ppu *PPU) getVramAddressInc() int {
 	if (ppu.ctrlReg.value & ctrlAddrInc) != 0 {
 		return 32
@@ -401,16 +479,15 @@ func (ppu *PPU) getVramAddressInc() int {
 	return 1
 }
 
-func (ppu *PPU) getScanlineVideo() []int {
-	scanlineVideoBuffer := make([]int, len(ppu.scanlineOffscreenBuffer))
+// renderScanlineVideo converts the offscreen scanline to RGB and indexed pixels.
+func (ppu *PPU) renderScanlineVideo(rgbPixels []int, indexedPixels []int) {
+	emphasis := (ppu.maskReg.value & maskColorIntensity) << 1
 	for i := range ppu.scanlineOffscreenBuffer {
 		paletteAddress := 0x3F00 | (ppu.scanlineOffscreenBuffer[i] & 0x1F)
-		colorIndex := ppu.vramMemory.read(paletteAddress)
-		rgb := paletteRGB[colorIndex&0x3F]
-		scanlineVideoBuffer[i] = rgb
+		colorIndex := ppu.vramMemory.read(paletteAddress) & 0x3F
+		rgbPixels[i] = paletteRGB[colorIndex]
+		indexedPixels[i] = colorIndex | emphasis
 	}
-
-	return scanlineVideoBuffer
 }
 
 func (ppu *PPU) isRenderingEnabled() bool {
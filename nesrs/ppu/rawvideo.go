@@ -0,0 +1,78 @@
+package ppu
+
+import (
+	"fmt"
+	"io"
+	"time"
+)
+
+// RawVideoReceiver streams frames as raw RGB24 (256x240, 3 bytes per pixel, no headers) and
+// optionally their timestamps to a sidecar in Matroska timecode v2 format (milliseconds, one
+// line per frame). Timestamps follow frame numbers, so frames not sent leave a gap.
+type RawVideoReceiver struct {
+	out       io.Writer
+	timecodes io.Writer
+	frames    int
+	number    int // Of the last frame written
+	buffer    []byte
+	err       error
+}
+
+// NewRawVideoReceiver writing frames to out and timestamps to timecodes (can be nil).
+func NewRawVideoReceiver(out io.Writer, timecodes io.Writer) *RawVideoReceiver {
+	rawVideoReceiver := RawVideoReceiver{
+		out:       out,
+		timecodes: timecodes,
+		number:    -1,
+		buffer:    make([]byte, 3*NESWidth*NESHeight)}
+
+	return &rawVideoReceiver
+}
+
+// ReceiveFrame as the frame after the last one written.
+func (rawVideoReceiver *RawVideoReceiver) ReceiveFrame(frame []int) {
+	rawVideoReceiver.ReceiveNumberedFrame(frame, rawVideoReceiver.number+1)
+}
+
+// ReceiveNumberedFrame .
+func (rawVideoReceiver *RawVideoReceiver) ReceiveNumberedFrame(frame []int, number int) {
+	if rawVideoReceiver.err != nil {
+		return
+	}
+
+	if rawVideoReceiver.timecodes != nil {
+		if rawVideoReceiver.frames == 0 {
+			_, rawVideoReceiver.err = io.WriteString(rawVideoReceiver.timecodes, "# timecode format v2\n")
+			if rawVideoReceiver.err != nil {
+				return
+			}
+		}
+
+		frameTime := FrameTime(number)
+		_, rawVideoReceiver.err = fmt.Fprintf(rawVideoReceiver.timecodes, "%d.%06d\n",
+			frameTime/time.Millisecond, frameTime%time.Millisecond)
+		if rawVideoReceiver.err != nil {
+			return
+		}
+	}
+
+	for i, rgb := range frame {
+		rawVideoReceiver.buffer[i*3] = byte(rgb >> 16)
+		rawVideoReceiver.buffer[i*3+1] = byte(rgb >> 8)
+		rawVideoReceiver.buffer[i*3+2] = byte(rgb)
+	}
+
+	_, rawVideoReceiver.err = rawVideoReceiver.out.Write(rawVideoReceiver.buffer)
+	rawVideoReceiver.frames++
+	rawVideoReceiver.number = number
+}
+
+// Frames - number of frames written.
+func (rawVideoReceiver *RawVideoReceiver) Frames() int {
+	return rawVideoReceiver.frames
+}
+
+// Err - first write error.
+func (rawVideoReceiver *RawVideoReceiver) Err() error {
+	return rawVideoReceiver.err
+}
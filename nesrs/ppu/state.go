@@ -0,0 +1,140 @@
+package ppu
+
+import (
+	"github.com/alpetkov/nesrs_go/nesrs/cartridge"
+	"github.com/alpetkov/nesrs_go/nesrs/state"
+)
+
+// SaveState of the PPU.
+func (ppu *PPU) SaveState(writer *state.Writer) {
+	// Registers
+	writer.WriteInt(ppu.ctrlReg.value)
+	writer.WriteInt(ppu.maskReg.value)
+	writer.WriteInt(ppu.statusReg.value)
+	writer.WriteInt(ppu.sprRAMAddressReg.value)
+	writer.WriteInt(ppu.vramAddressScrollReg.address)
+	writer.WriteInt(ppu.vramAddressScrollReg.lastValue)
+	writer.WriteInt(ppu.vramAddressScrollReg.tempAddress)
+	writer.WriteBool(ppu.vramAddressScrollReg.toggle)
+	writer.WriteInt(ppu.vramAddressScrollReg.bgFineX)
+
+	// Memory
+	for _, nt := range ppu.vramMemory.ntVRAM {
+		writer.WriteArray(nt)
+	}
+	writer.WriteArray(ppu.vramMemory.backgroundPaletteRAM[:])
+	writer.WriteArray(ppu.vramMemory.spritePaletteRAM[:])
+	writer.WriteInt(ppu.vramMemory.ppuCycle)
+	writer.WriteArray(ppu.sprMemory.ram[:])
+	writer.WriteArray(ppu.sprMemory.tempMemory[:])
+
+	// Renderers
+	ppu.backgroundRenderer.saveState(writer)
+	ppu.spriteRenderer.saveState(writer)
+
+	// Pixel buffers
+	writer.WriteArray(ppu.scanlineOffscreenBuffer[:])
+	writer.WriteArray(ppu.frameBuffer[:])
+	writer.WriteArray(ppu.indexedFrameBuffer[:])
+
+	// Counters
+	writer.WriteInt(ppu.currentCycle)
+	writer.WriteInt(ppu.currentScanline)
+	writer.WriteInt(ppu.currentScanlineCyclesCount)
+	writer.WriteBool(ppu.isOddFrame)
+	writer.WriteBool(ppu.canSetVblForFrame)
+}
+
+// LoadState of the PPU.
+func (ppu *PPU) LoadState(reader *state.Reader) {
+	// Registers
+	ppu.ctrlReg.value = reader.ReadIntRange(0, 0x100)
+	ppu.maskReg.value = reader.ReadIntRange(0, 0x100)
+	ppu.statusReg.value = reader.ReadIntRange(0, 0x100)
+	ppu.sprRAMAddressReg.value = reader.ReadIntRange(0, len(ppu.sprMemory.ram))
+	ppu.vramAddressScrollReg.address = reader.ReadIntRange(0, 0x8000)
+	ppu.vramAddressScrollReg.lastValue = reader.ReadIntRange(0, 0x100)
+	ppu.vramAddressScrollReg.tempAddress = reader.ReadIntRange(0, 0x8000)
+	ppu.vramAddressScrollReg.toggle = reader.ReadBool()
+	ppu.vramAddressScrollReg.bgFineX = reader.ReadIntRange(0, 8)
+
+	// Memory
+	for _, nt := range ppu.vramMemory.ntVRAM {
+		reader.ReadArray(nt)
+	}
+	reader.ReadArray(ppu.vramMemory.backgroundPaletteRAM[:])
+	reader.ReadArray(ppu.vramMemory.spritePaletteRAM[:])
+	ppu.vramMemory.ppuCycle = reader.ReadIntRange(0, cartridge.PPUCycleMask+1)
+	reader.ReadArray(ppu.sprMemory.ram[:])
+	reader.ReadArray(ppu.sprMemory.tempMemory[:])
+
+	// Renderers
+	ppu.backgroundRenderer.loadState(reader)
+	ppu.spriteRenderer.loadState(reader)
+
+	// Pixel buffers
+	reader.ReadArray(ppu.scanlineOffscreenBuffer[:])
+	reader.ReadArray(ppu.frameBuffer[:])
+	reader.ReadArray(ppu.indexedFrameBuffer[:])
+
+	// Counters
+	ppu.currentCycle = reader.ReadIntRange(-1, CyclesCountInScanline)
+	ppu.currentScanline = reader.ReadIntRange(0, ScanlineCountInFrame)
+	ppu.currentScanlineCyclesCount = reader.ReadIntRange(CyclesCountInScanline-1, CyclesCountInScanline+1)
+	ppu.isOddFrame = reader.ReadBool()
+	ppu.canSetVblForFrame = reader.ReadBool()
+}
+
+func (renderer *backgroundRenderer) saveState(writer *state.Writer) {
+	latch := &renderer.tileLatch
+	writer.WriteInt(latch.tileIndex)
+	writer.WriteInt(latch.tileDataLow)
+	writer.WriteInt(latch.tileDataHigh)
+	writer.WriteInt(latch.attributePaletteData)
+
+	pipeline := &renderer.pipeline
+	writer.WriteInt(pipeline.tileDataLow)
+	writer.WriteInt(pipeline.tileDataHigh)
+	writer.WriteInt(pipeline.attributePalleteDataLow)
+	writer.WriteInt(pipeline.attributePalleteDataHigh)
+}
+
+func (renderer *backgroundRenderer) loadState(reader *state.Reader) {
+	latch := &renderer.tileLatch
+	latch.tileIndex = reader.ReadIntRange(0, 0x100)
+	latch.tileDataLow = reader.ReadInt()
+	latch.tileDataHigh = reader.ReadInt()
+	latch.attributePaletteData = reader.ReadInt()
+
+	pipeline := &renderer.pipeline
+	pipeline.tileDataLow = reader.ReadInt()
+	pipeline.tileDataHigh = reader.ReadInt()
+	pipeline.attributePalleteDataLow = reader.ReadInt()
+	pipeline.attributePalleteDataHigh = reader.ReadInt()
+}
+
+func (renderer *spriteRenderer) saveState(writer *state.Writer) {
+	for i := range renderer.pipelineMemory {
+		pipeline := &renderer.pipelineMemory[i]
+		writer.WriteInt(pipeline.tileDataLow)
+		writer.WriteInt(pipeline.tileDataHigh)
+		writer.WriteInt(pipeline.attributePaletteData)
+		writer.WriteBool(pipeline.isHighPriority)
+		writer.WriteInt(pipeline.xPosition)
+		writer.WriteBool(pipeline.isSpriteZero)
+	}
+	writer.WriteBool(renderer.isSpriteZeroInRange)
+}
+
+func (renderer *spriteRenderer) loadState(reader *state.Reader) {
+	for i := range renderer.pipelineMemory {
+		pipeline := &renderer.pipelineMemory[i]
+		pipeline.tileDataLow = reader.ReadInt()
+		pipeline.tileDataHigh = reader.ReadInt()
+		pipeline.attributePaletteData = reader.ReadInt()
+		pipeline.isHighPriority = reader.ReadBool()
+		pipeline.xPosition = reader.ReadInt()
+		pipeline.isSpriteZero = reader.ReadBool()
+	}
+	renderer.isSpriteZeroInRange = reader.ReadBool()
+}
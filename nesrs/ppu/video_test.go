@@ -0,0 +1,151 @@
+package ppu
+
+import (
+	"bytes"
+	"image/gif"
+	"image/png"
+	"strings"
+	"testing"
+	"time"
+)
+
+func testFrame(rgb int) []int {
+	frame := make([]int, NESWidth*NESHeight)
+	for i := range frame {
+		frame[i] = rgb
+	}
+	frame[0] = paletteRGB[0x16]
+
+	return frame
+}
+
+func TestPNGVideoReceiver(t *testing.T) {
+	pngVideoReceiver := new(PNGVideoReceiver)
+	pngVideoReceiver.ReceiveFrame(testFrame(paletteRGB[0x21]))
+
+	var out bytes.Buffer
+	if err := pngVideoReceiver.Write(&out); err != nil {
+		t.Fatal(err)
+	}
+	img, err := png.Decode(&out)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if r, g, b, _ := img.At(0, 0).RGBA(); int(r>>8)<<16|int(g>>8)<<8|int(b>>8) != paletteRGB[0x16] {
+		t.Errorf("Unexpected pixel at 0,0")
+	}
+	if r, g, b, _ := img.At(NESWidth-1, NESHeight-1).RGBA(); int(r>>8)<<16|int(g>>8)<<8|int(b>>8) != paletteRGB[0x21] {
+		t.Errorf("Unexpected pixel at 255,239")
+	}
+}
+
+func TestGIFVideoReceiver(t *testing.T) {
+	gifVideoReceiver := &GIFVideoReceiver{First: 2, Count: 9}
+	if err := gifVideoReceiver.Write(new(bytes.Buffer)); err != ErrNoGIFFrames {
+		t.Errorf("Expected ErrNoGIFFrames, got %v", err)
+	}
+
+	for i := 0; i < 20; i++ {
+		gifVideoReceiver.ReceiveFrame(testFrame(paletteRGB[i]))
+	}
+	// Frames 2, 5 & 8
+	if gifVideoReceiver.Frames() != 3 {
+		t.Fatalf("Expected 3 frames, got %d", gifVideoReceiver.Frames())
+	}
+
+	var out bytes.Buffer
+	if err := gifVideoReceiver.Write(&out); err != nil {
+		t.Fatal(err)
+	}
+	decoded, err := gif.DecodeAll(&out)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(decoded.Image) != 3 {
+		t.Fatalf("Expected 3 images, got %d", len(decoded.Image))
+	}
+	for i, img := range decoded.Image {
+		if len(img.Palette) != len(paletteRGB) {
+			t.Errorf("Expected NES palette, got %d colors", len(img.Palette))
+		}
+		if img.ColorIndexAt(0, 0) != 0x16 || int(img.ColorIndexAt(1, 0)) != 2+i*3 {
+			t.Errorf("Unexpected colors in frame %d", i)
+		}
+	}
+	// 3 frames at 60.0988 fps: 4.99, 9.98, 14.98 centiseconds
+	if decoded.Delay[0] != 5 || decoded.Delay[1] != 5 || decoded.Delay[2] != 5 {
+		t.Errorf("Unexpected delays %v", decoded.Delay)
+	}
+}
+
+func TestFrameTime(t *testing.T) {
+	if FrameTime(0) != 0 || FrameTime(1) != 16639263*time.Nanosecond {
+		t.Errorf("Unexpected frame times %v %v", FrameTime(0), FrameTime(1))
+	}
+	// An hour is 216355.8 frames
+	if FrameTime(216355) >= time.Hour || FrameTime(216356) < time.Hour {
+		t.Errorf("Frame time drifts %v %v", FrameTime(216355), FrameTime(216356))
+	}
+}
+
+func TestY4MVideoReceiver(t *testing.T) {
+	var out bytes.Buffer
+	y4mVideoReceiver := NewY4MVideoReceiver(&out)
+	y4mVideoReceiver.ReceiveFrame(testFrame(0xFFFFFF))
+	y4mVideoReceiver.ReceiveFrame(testFrame(0x000000))
+
+	header := "YUV4MPEG2 W256 H240 F39375000:655171 Ip A8:7 C444\n"
+	frameSize := len("FRAME\n") + 3*NESWidth*NESHeight
+	if y4mVideoReceiver.Err() != nil || y4mVideoReceiver.Frames() != 2 {
+		t.Fatalf("Unexpected result %v %d", y4mVideoReceiver.Err(), y4mVideoReceiver.Frames())
+	}
+	if out.Len() != len(header)+2*frameSize || !strings.HasPrefix(out.String(), header+"FRAME\n") {
+		t.Fatalf("Unexpected stream (%d bytes)", out.Len())
+	}
+
+	// White: Y 235, black: Y 16, both U & V 128
+	data := out.Bytes()[len(header):]
+	planes := []struct {
+		offset  int
+		y, u, v byte
+	}{{len("FRAME\n"), 235, 128, 128}, {frameSize + len("FRAME\n"), 16, 128, 128}}
+	for _, plane := range planes {
+		pixel := plane.offset + NESWidth*NESHeight - 1
+		y, u, v := data[pixel], data[pixel+NESWidth*NESHeight], data[pixel+2*NESWidth*NESHeight]
+		if y != plane.y || u != plane.u || v != plane.v {
+			t.Errorf("Expected YUV %d %d %d, got %d %d %d", plane.y, plane.u, plane.v, y, u, v)
+		}
+	}
+}
+
+func TestRawVideoReceiver(t *testing.T) {
+	var out, timecodes bytes.Buffer
+	rawVideoReceiver := NewRawVideoReceiver(&out, &timecodes)
+	for i := 0; i < 3; i++ {
+		rawVideoReceiver.ReceiveFrame(testFrame(0x123456))
+	}
+
+	if rawVideoReceiver.Err() != nil || out.Len() != 3*3*NESWidth*NESHeight {
+		t.Fatalf("Unexpected stream (%d bytes, %v)", out.Len(), rawVideoReceiver.Err())
+	}
+	if !bytes.HasSuffix(out.Bytes(), []byte{0x12, 0x34, 0x56}) {
+		t.Errorf("Unexpected last pixel")
+	}
+	expected := "# timecode format v2\n0.000000\n16.639263\n33.278526\n"
+	if timecodes.String() != expected {
+		t.Errorf("Unexpected timecodes %q", timecodes.String())
+	}
+
+	// Skipped frames 3 and 4
+	timecodes.Reset()
+	rawVideoReceiver.ReceiveNumberedFrame(testFrame(0x123456), 5)
+	rawVideoReceiver.ReceiveFrame(testFrame(0x123456))
+	if expected := "83.196317\n99.835580\n"; timecodes.String() != expected {
+		t.Errorf("Unexpected timecodes %q after skipped frames", timecodes.String())
+	}
+	if rawVideoReceiver.Frames() != 5 {
+		t.Errorf("Wrong frames count %v", rawVideoReceiver.Frames())
+	}
+}
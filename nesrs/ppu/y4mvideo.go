@@ -0,0 +1,75 @@
+package ppu
+
+import (
+	"fmt"
+	"io"
+)
+
+// Y4MVideoReceiver streams frames as YUV4MPEG2 (4:4:4, BT.601 limited range) at the exact NTSC
+// frame rate, e.g. to pipe into an encoder. Every received frame is written, so skipped frames
+// (see nesrs.SetMaxFrameSkip) are missing from the stream.
+type Y4MVideoReceiver struct {
+	out    io.Writer
+	frames int
+	buffer []byte
+	err    error
+}
+
+// NewY4MVideoReceiver writing to out.
+func NewY4MVideoReceiver(out io.Writer) *Y4MVideoReceiver {
+	y4mVideoReceiver := Y4MVideoReceiver{
+		out:    out,
+		buffer: make([]byte, len("FRAME\n")+3*NESWidth*NESHeight)}
+
+	return &y4mVideoReceiver
+}
+
+// ReceiveFrame .
+func (y4mVideoReceiver *Y4MVideoReceiver) ReceiveFrame(frame []int) {
+	if y4mVideoReceiver.err != nil {
+		return
+	}
+
+	if y4mVideoReceiver.frames == 0 {
+		// NES pixels are 8:7
+		_, y4mVideoReceiver.err = fmt.Fprintf(y4mVideoReceiver.out, "YUV4MPEG2 W%d H%d F%d:%d Ip A8:7 C444\n",
+			NESWidth, NESHeight, FrameRateNumerator, FrameRateDenominator)
+		if y4mVideoReceiver.err != nil {
+			return
+		}
+	}
+
+	header := copy(y4mVideoReceiver.buffer, "FRAME\n")
+	yPlane := y4mVideoReceiver.buffer[header : header+NESWidth*NESHeight]
+	uPlane := y4mVideoReceiver.buffer[header+NESWidth*NESHeight : header+2*NESWidth*NESHeight]
+	vPlane := y4mVideoReceiver.buffer[header+2*NESWidth*NESHeight:]
+	for i, rgb := range frame {
+		yPlane[i], uPlane[i], vPlane[i] = rgbToYUV(rgb)
+	}
+
+	_, y4mVideoReceiver.err = y4mVideoReceiver.out.Write(y4mVideoReceiver.buffer)
+	y4mVideoReceiver.frames++
+}
+
+// Frames - number of frames written.
+func (y4mVideoReceiver *Y4MVideoReceiver) Frames() int {
+	return y4mVideoReceiver.frames
+}
+
+// Err - first write error.
+func (y4mVideoReceiver *Y4MVideoReceiver) Err() error {
+	return y4mVideoReceiver.err
+}
+
+// rgbToYUV - BT.601 limited range.
+func rgbToYUV(rgb int) (byte, byte, byte) {
+	r := (rgb >> 16) & 0xFF
+	g := (rgb >> 8) & 0xFF
+	b := rgb & 0xFF
+
+	y := ((66*r + 129*g + 25*b + 128) >> 8) + 16
+	u := ((-38*r - 74*g + 112*b + 128) >> 8) + 128
+	v := ((112*r - 94*g - 18*b + 128) >> 8) + 128
+
+	return byte(y), byte(u), byte(v)
+}
@@ -0,0 +1,250 @@
+package nesrs
+
+import (
+	"bytes"
+	"compress/flate"
+	"errors"
+	"io/ioutil"
+
+	"github.com/alpetkov/nesrs_go/nesrs/movie"
+	"github.com/alpetkov/nesrs_go/nesrs/ppu"
+	"github.com/alpetkov/nesrs_go/nesrs/state"
+)
+
+// Rewind errors.
+var (
+	ErrRewindDisabled = errors.New("nesrs: rewind is disabled")
+	ErrRewindTooFar   = errors.New("nesrs: not enough rewind history")
+)
+
+// Approximate memory used by the input of one frame.
+const rewindInputSize = (maxPlayers + 1) * 8
+
+// rewindSnapshot - machine state at the end of the frame. Only the newest snapshot is kept whole,
+// older ones are stored compressed as XOR against the next (newer) snapshot.
+type rewindSnapshot struct {
+	frame int
+	delta []byte
+}
+
+// rewindBuffer keeps snapshots taken every interval frames and the input of every frame since
+// the oldest snapshot, so any frame in between can be re-simulated.
+type rewindBuffer struct {
+	interval  int
+	budget    int              // Bytes
+	size      int              // Bytes used by snapshots and inputs
+	snapshots []rewindSnapshot // Oldest first
+	latest    []byte           // Whole state of the newest snapshot
+	inputs    []movie.Frame    // Input polled at the end of each frame since the oldest snapshot
+}
+
+func newRewindBuffer(interval int, budget int) *rewindBuffer {
+	return &rewindBuffer{interval: interval, budget: budget}
+}
+
+func (rewind *rewindBuffer) clear() {
+	rewind.snapshots = nil
+	rewind.latest = nil
+	rewind.inputs = nil
+	rewind.size = 0
+}
+
+// oldestFrame in the buffer (-1 when empty).
+func (rewind *rewindBuffer) oldestFrame() int {
+	if len(rewind.snapshots) == 0 {
+		return -1
+	}
+
+	return rewind.snapshots[0].frame
+}
+
+// recordInput of the frame. Frames are recorded in order.
+func (rewind *rewindBuffer) recordInput(input movie.Frame) {
+	if len(rewind.snapshots) == 0 {
+		return
+	}
+
+	rewind.inputs = append(rewind.inputs, input)
+	rewind.size += rewindInputSize
+}
+
+// input polled at the end of the frame.
+func (rewind *rewindBuffer) input(frame int) movie.Frame {
+	return rewind.inputs[frame-rewind.oldestFrame()]
+}
+
+// addSnapshot of the machine state at the end of the frame.
+func (rewind *rewindBuffer) addSnapshot(frame int, snapshot []byte) {
+	if len(rewind.snapshots) == 0 {
+		rewind.inputs = append(rewind.inputs, movie.Frame{})
+		rewind.size += rewindInputSize
+	} else {
+		// Previous newest snapshot becomes a delta
+		last := &rewind.snapshots[len(rewind.snapshots)-1]
+		last.delta = compressDelta(rewind.latest, snapshot)
+		rewind.size += len(last.delta) - len(rewind.latest)
+	}
+
+	rewind.snapshots = append(rewind.snapshots, rewindSnapshot{frame: frame})
+	rewind.latest = snapshot
+	rewind.size += len(snapshot)
+
+	// Drop oldest snapshots (and their inputs) over budget. The newest is always kept.
+	for rewind.size > rewind.budget && len(rewind.snapshots) > 1 {
+		dropped := rewind.snapshots[1].frame - rewind.snapshots[0].frame
+		rewind.size -= len(rewind.snapshots[0].delta) + dropped*rewindInputSize
+		rewind.inputs = rewind.inputs[dropped:]
+		rewind.snapshots = rewind.snapshots[1:]
+	}
+}
+
+// restore the newest snapshot taken at or before the target frame. History after it is dropped.
+func (rewind *rewindBuffer) restore(target int) (int, []byte, error) {
+	if target < rewind.oldestFrame() || len(rewind.snapshots) == 0 {
+		return 0, nil, ErrRewindTooFar
+	}
+
+	snapshot := rewind.latest
+	i := len(rewind.snapshots) - 1
+	for rewind.snapshots[i].frame > target {
+		i--
+		var err error
+		if snapshot, err = decompressDelta(snapshot, rewind.snapshots[i].delta); err != nil {
+			return 0, nil, err
+		}
+	}
+
+	// Restored snapshot becomes the newest
+	for _, dropped := range rewind.snapshots[i:] {
+		rewind.size -= len(dropped.delta)
+	}
+	rewind.size += len(snapshot) - len(rewind.latest)
+	rewind.snapshots = rewind.snapshots[:i+1]
+	rewind.snapshots[i].delta = nil
+	rewind.latest = snapshot
+
+	return rewind.snapshots[i].frame, snapshot, nil
+}
+
+// truncateInputs recorded after the frame.
+func (rewind *rewindBuffer) truncateInputs(frame int) {
+	count := frame - rewind.oldestFrame() + 1
+	rewind.size -= (len(rewind.inputs) - count) * rewindInputSize
+	rewind.inputs = rewind.inputs[:count]
+}
+
+func compressDelta(older []byte, newer []byte) []byte {
+	delta := make([]byte, len(older))
+	for i := range older {
+		delta[i] = older[i] ^ newer[i]
+	}
+
+	var compressed bytes.Buffer
+	writer, _ := flate.NewWriter(&compressed, flate.BestSpeed)
+	writer.Write(delta)
+	writer.Close()
+
+	return compressed.Bytes()
+}
+
+func decompressDelta(newer []byte, compressed []byte) ([]byte, error) {
+	older, err := ioutil.ReadAll(flate.NewReader(bytes.NewReader(compressed)))
+	if err != nil {
+		return nil, err
+	}
+	for i := range older {
+		older[i] ^= newer[i]
+	}
+
+	return older, nil
+}
+
+// SetRewind enables rewinding with a snapshot every interval frames, keeping at most budget bytes
+// of history. Zero interval disables it. Shorter interval means faster rewinding but less history.
+func (nes *NES) SetRewind(interval int, budget int) {
+	nes.lock.Lock()
+	defer nes.lock.Unlock()
+
+	nes.rewind = nil
+	if interval > 0 {
+		nes.rewind = newRewindBuffer(interval, budget)
+		nes.takeSnapshot()
+	}
+}
+
+// Rewind goes back the given number of frames, restoring the nearest older snapshot and
+// re-simulating recorded input up to the exact frame. The frame is sent to the video receiver.
+func (nes *NES) Rewind(frames int) error {
+	nes.lock.Lock()
+	defer nes.lock.Unlock()
+
+	if nes.rewind == nil {
+		return ErrRewindDisabled
+	}
+	if frames < 0 {
+		return ErrRewindTooFar
+	}
+
+	// Keep current state in case the snapshot can't be loaded
+	var backup bytes.Buffer
+	if err := nes.saveComponents(state.NewWriter(&backup)); err != nil {
+		return err
+	}
+
+	target := nes.frame - frames
+	frame, snapshot, err := nes.rewind.restore(target)
+	if err != nil {
+		return err
+	}
+
+	// Reset requested since the last frame isn't in the history yet, keep it
+	isResetPending := nes.input.isResetPending
+	if err := nes.loadComponents(state.NewReader(bytes.NewReader(snapshot))); err != nil {
+		nes.loadComponents(state.NewReader(&backup))
+
+		// History doesn't lead to the current state anymore
+		nes.rewind.clear()
+		nes.takeSnapshot()
+		return err
+	}
+	nes.input.isResetPending = isResetPending
+	nes.frame = frame
+
+	// Re-simulate silently
+	nes.isReplaying = true
+	nes.apu.SetMuted(true)
+	for nes.frame < target {
+		nes.runFrame()
+	}
+	nes.isReplaying = false
+	nes.apu.SetMuted(false)
+	nes.rewind.truncateInputs(target)
+	nes.truncateMovie()
+
+	if indexedVideoReceiver, ok := nes.videoReceiver.(ppu.IndexedVideoReceiver); ok {
+		indexedVideoReceiver.ReceiveIndexedFrame(nes.ppu.IndexedFrame())
+	}
+	nes.sendFrame(nes.ppu.Frame(), nes.frame-1)
+
+	return nil
+}
+
+// RewindAvailable - number of frames Rewind can go back.
+func (nes *NES) RewindAvailable() int {
+	nes.lock.Lock()
+	defer nes.lock.Unlock()
+
+	if nes.rewind == nil || nes.rewind.oldestFrame() < 0 {
+		return 0
+	}
+
+	return nes.frame - nes.rewind.oldestFrame()
+}
+
+// takeSnapshot for rewinding at the current frame.
+func (nes *NES) takeSnapshot() {
+	var snapshot bytes.Buffer
+	if err := nes.saveComponents(state.NewWriter(&snapshot)); err == nil {
+		nes.rewind.addSnapshot(nes.frame, snapshot.Bytes())
+	}
+}
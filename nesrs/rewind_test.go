@@ -0,0 +1,143 @@
+package nesrs
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/alpetkov/nesrs_go/nesrs/controller"
+)
+
+// testInputProvider presses different buttons every time it's polled.
+type testInputProvider struct {
+	polls int
+}
+
+func (provider *testInputProvider) ReadInput(player int) int {
+	if player == 0 {
+		provider.polls++
+	}
+	return (provider.polls * (player + 1)) & 0xFF
+}
+
+func TestRewind(t *testing.T) {
+	nes, _ := New(testROM(), nil, nil, &testInputProvider{})
+	nes.SetRewind(10, 8<<20)
+	nes.Start()
+
+	for nes.frame < 23 {
+		nes.runFrame()
+	}
+	var expected bytes.Buffer
+	nes.SaveState(&expected)
+
+	for nes.frame < 35 {
+		nes.runFrame()
+	}
+	if available := nes.RewindAvailable(); available != 35 {
+		t.Errorf("Wrong available frames %v", available)
+	}
+	if err := nes.Rewind(12); err != nil {
+		t.Fatal(err)
+	}
+
+	var actual bytes.Buffer
+	nes.SaveState(&actual)
+	if nes.frame != 23 || !bytes.Equal(expected.Bytes(), actual.Bytes()) {
+		t.Errorf("Wrong state after rewinding to frame %v", nes.frame)
+	}
+
+	if err := nes.Rewind(24); err != ErrRewindTooFar {
+		t.Errorf("Rewind past history: %v", err)
+	}
+}
+
+func TestRewindBudget(t *testing.T) {
+	nes, _ := New(testROM(), nil, nil, nil)
+	nes.SetRewind(5, 1<<20)
+	nes.Start()
+	nes.rewind.budget = len(nes.rewind.latest) + 2048
+
+	for nes.frame < 300 {
+		nes.runFrame()
+	}
+	if nes.rewind.size > nes.rewind.budget {
+		t.Errorf("Rewind buffer %v over budget", nes.rewind.size)
+	}
+
+	available := nes.RewindAvailable()
+	if available == 0 || available >= 300 {
+		t.Errorf("Wrong available frames %v", available)
+	}
+	if err := nes.Rewind(available); err != nil {
+		t.Errorf("Rewind to the oldest frame: %v", err)
+	}
+}
+
+func TestRewindControllerChange(t *testing.T) {
+	nes, _ := New(testROM(), nil, nil, &testInputProvider{})
+	nes.SetRewind(5, 8<<20)
+	nes.Start()
+
+	for nes.frame < 7 {
+		nes.runFrame()
+	}
+	nes.ConnectZapper()
+	for nes.frame < 17 {
+		nes.runFrame()
+	}
+	var expected bytes.Buffer
+	nes.SaveState(&expected)
+
+	for nes.frame < 20 {
+		nes.runFrame()
+	}
+	if available := nes.RewindAvailable(); available != 13 {
+		t.Errorf("Wrong available frames %v", available)
+	}
+	if err := nes.Rewind(3); err != nil {
+		t.Fatal(err)
+	}
+	var actual bytes.Buffer
+	nes.SaveState(&actual)
+	if nes.frame != 17 || !bytes.Equal(expected.Bytes(), actual.Bytes()) {
+		t.Errorf("Wrong state after rewinding to frame %v", nes.frame)
+	}
+
+	// History with the previous controller is dropped
+	nes.SetFourScore(true)
+	for nes.frame < 25 {
+		nes.runFrame()
+	}
+	if err := nes.Rewind(9); err != ErrRewindTooFar {
+		t.Errorf("Rewind past controller change: %v", err)
+	}
+	if err := nes.Rewind(8); err != nil {
+		t.Errorf("Rewind to controller change: %v", err)
+	}
+}
+
+func TestRewindLoadError(t *testing.T) {
+	nes, _ := New(testROM(), nil, nil, &testInputProvider{})
+	nes.SetRewind(5, 8<<20)
+	nes.Start()
+	for nes.frame < 12 {
+		nes.runFrame()
+	}
+
+	// Snapshots have joypad state the Zapper can't load
+	nes.cpuMemory.SetController2(controller.NewZapper(nes.ppu))
+	var expected bytes.Buffer
+	nes.SaveState(&expected)
+	if err := nes.Rewind(2); err != ErrStateDevices {
+		t.Errorf("Wrong error %v", err)
+	}
+
+	var actual bytes.Buffer
+	nes.SaveState(&actual)
+	if nes.frame != 12 || !bytes.Equal(expected.Bytes(), actual.Bytes()) {
+		t.Errorf("Machine changed by failed rewind")
+	}
+	for nes.frame < 20 {
+		nes.runFrame()
+	}
+}
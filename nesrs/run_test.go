@@ -0,0 +1,234 @@
+package nesrs
+
+import (
+	"bytes"
+	"context"
+	"runtime"
+	"testing"
+
+	"github.com/alpetkov/nesrs_go/nesrs/apu"
+	"github.com/alpetkov/nesrs_go/nesrs/ppu"
+)
+
+func TestRunFrame(t *testing.T) {
+	nes, _ := New(testROM(), nil, nil, nil)
+	nes.Start()
+
+	for i := 1; i <= 3; i++ {
+		nes.RunFrame()
+		if nes.Frame() != i {
+			t.Errorf("Wrong frame %v, right %v", nes.Frame(), i)
+		}
+	}
+
+	// NMI handler counts frames at $11
+	if count := nes.Peek(0x0011); count != 3 {
+		t.Errorf("Wrong NMI count %v", count)
+	}
+}
+
+// testIndexedVideoReceiver keeps the last RGB and indexed frames.
+type testIndexedVideoReceiver struct {
+	frame        []int
+	indexedFrame []int
+}
+
+func (videoReceiver *testIndexedVideoReceiver) ReceiveFrame(frame []int) {
+	videoReceiver.frame = append(videoReceiver.frame[:0], frame...)
+}
+
+func (videoReceiver *testIndexedVideoReceiver) ReceiveIndexedFrame(frame []int) {
+	videoReceiver.indexedFrame = append(videoReceiver.indexedFrame[:0], frame...)
+}
+
+func TestIndexedFrame(t *testing.T) {
+	videoReceiver := &testIndexedVideoReceiver{}
+	nes, _ := New(testROM(), videoReceiver, nil, nil)
+	nes.Start()
+	nes.RunFrame()
+
+	// Backdrop color $21 with red and blue emphasis
+	nes.cpuMemory.Write(0x2006, 0x3F)
+	nes.cpuMemory.Write(0x2006, 0x00)
+	nes.cpuMemory.Write(0x2007, 0x21)
+	nes.cpuMemory.Write(0x2001, 0xBE)
+	nes.RunFrame()
+
+	if len(videoReceiver.indexedFrame) != len(videoReceiver.frame) {
+		t.Fatalf("Wrong indexed frame size %v", len(videoReceiver.indexedFrame))
+	}
+	for i, pixel := range videoReceiver.indexedFrame {
+		if pixel != 0x21|0x140 {
+			t.Fatalf("Wrong indexed pixel %03X at %v", pixel, i)
+		}
+		if ppu.IndexedToRGB(pixel) != videoReceiver.frame[i] {
+			t.Fatalf("Indexed pixel %03X doesn't match RGB %06X at %v", pixel, videoReceiver.frame[i], i)
+		}
+	}
+}
+
+func TestRunCycles(t *testing.T) {
+	nes, _ := New(testROM(), nil, nil, nil)
+	nes.Start()
+
+	if cycles := nes.RunCycles(1000); cycles < 1000 || cycles > 1006 {
+		t.Errorf("Wrong cycles %v", cycles)
+	}
+
+	nes.RunUntil(func(nes *NES) bool { return nes.Peek(0x0010) == 0x80 })
+	if nes.Frame() != 0 {
+		t.Errorf("Ran too far (frame %v)", nes.Frame())
+	}
+}
+
+func TestRunControl(t *testing.T) {
+	nes, _ := New(testROM(), nil, nil, nil)
+	nes.Start()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	result := make(chan error)
+	go func() {
+		result <- nes.RunContext(ctx)
+	}()
+
+	nes.Pause()
+	frame := nes.Frame()
+	if !nes.IsPaused() || nes.Frame() != frame {
+		t.Errorf("Not paused")
+	}
+
+	var snapshot bytes.Buffer
+	if err := nes.SaveState(&snapshot); err != nil {
+		t.Fatal(err)
+	}
+	nes.Resume()
+	nes.Reset()
+	for nes.Frame() < frame+3 {
+		runtime.Gosched()
+	}
+	if err := nes.LoadState(&snapshot); err != nil {
+		t.Fatal(err)
+	}
+
+	nes.Pause()
+	cancel()
+	if err := <-result; err != context.Canceled {
+		t.Errorf("Wrong result %v", err)
+	}
+
+	// Stop ends Run
+	nes.Start()
+	go func() {
+		result <- nes.RunContext(context.Background())
+	}()
+	nes.Stop()
+	if err := <-result; err != nil {
+		t.Errorf("Wrong result %v", err)
+	}
+}
+
+func TestAudioFlush(t *testing.T) {
+	audioReceiver := &apu.WAVAudioReceiver{}
+	nes, _ := New(testROM(), nil, audioReceiver, nil)
+	nes.Start()
+
+	// Less than a buffer (1/60 s) is delivered on Stop
+	nes.RunCycles(10000)
+	var before bytes.Buffer
+	audioReceiver.Write(&before)
+	nes.Stop()
+	var after bytes.Buffer
+	audioReceiver.Write(&after)
+	if before.Len() != 44 || after.Len() <= 44 {
+		t.Errorf("Wrong WAV sizes %v %v", before.Len(), after.Len())
+	}
+
+	// Frame ends flush audio too
+	nes.Start()
+	nes.RunFrame()
+	before.Reset()
+	audioReceiver.Write(&before)
+	nes.Stop()
+	after.Reset()
+	audioReceiver.Write(&after)
+	if before.Len() != after.Len() {
+		t.Errorf("Audio not flushed at the end of the frame (%v, %v)", before.Len(), after.Len())
+	}
+}
+
+func TestReset(t *testing.T) {
+	nes, _ := New(testROM(), nil, nil, nil)
+	nes.Start()
+	nes.RunFrame()
+
+	// Applied before the next op
+	nes.Reset()
+	nes.RunCycles(1)
+	if nes.cpu.PC != 0x8000 || nes.cpu.S != 0xFF {
+		t.Errorf("Not reset (PC %04X, S %02X)", nes.cpu.PC, nes.cpu.S)
+	}
+
+	// Saved along the machine
+	nes.RunFrame()
+	nes.Reset()
+	var snapshot bytes.Buffer
+	nes.SaveState(&snapshot)
+	loaded, _ := New(testROM(), nil, nil, nil)
+	loaded.Start()
+	if err := loaded.LoadState(&snapshot); err != nil {
+		t.Fatal(err)
+	}
+	loaded.RunCycles(1)
+	if loaded.cpu.PC != 0x8000 {
+		t.Errorf("Loaded state not reset (PC %04X)", loaded.cpu.PC)
+	}
+
+	// Delayed to the end of the frame while rewinding is enabled
+	nes.SetRewind(10, 1<<20)
+	nes.RunFrame()
+	nes.Reset()
+	snapshot.Reset()
+	nes.SaveState(&snapshot)
+	nes.RunCycles(1000)
+	if nes.cpu.PC == 0x8000 {
+		t.Errorf("Reset before the end of the frame")
+	}
+	if err := loaded.LoadState(&snapshot); err != nil {
+		t.Fatal(err)
+	}
+	for _, machine := range []*NES{nes, loaded} {
+		frame := machine.Frame()
+		machine.RunUntil(func(nes *NES) bool { return nes.cpu.PC == 0x8000 })
+		if machine.Frame() != frame+1 {
+			t.Errorf("Reset at frame %v, expected %v", machine.Frame(), frame+1)
+		}
+	}
+}
+
+// testNumberedVideoReceiver keeps numbers of the received frames.
+type testNumberedVideoReceiver struct {
+	numbers []int
+}
+
+func (videoReceiver *testNumberedVideoReceiver) ReceiveFrame(frame []int) {
+	videoReceiver.numbers = append(videoReceiver.numbers, -1)
+}
+
+func (videoReceiver *testNumberedVideoReceiver) ReceiveNumberedFrame(frame []int, number int) {
+	videoReceiver.numbers = append(videoReceiver.numbers, number)
+}
+
+func TestNumberedFrame(t *testing.T) {
+	videoReceiver := &testNumberedVideoReceiver{}
+	nes, _ := New(testROM(), videoReceiver, nil, nil)
+	nes.Start()
+
+	nes.RunFrame()
+	nes.isVideoSkipped = true
+	nes.RunFrame()
+	nes.isVideoSkipped = false
+	nes.RunFrame()
+	if len(videoReceiver.numbers) != 2 || videoReceiver.numbers[0] != 0 || videoReceiver.numbers[1] != 2 {
+		t.Errorf("Wrong frame numbers %v", videoReceiver.numbers)
+	}
+}
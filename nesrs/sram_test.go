@@ -0,0 +1,97 @@
+package nesrs
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/alpetkov/nesrs_go/nesrs/cartridge"
+)
+
+// batteryROM - testROM with battery-backed PRG RAM.
+func batteryROM() []byte {
+	rom := testROM()
+	rom[6] |= 0x02
+	return rom
+}
+
+func TestSRAMRoundTrip(t *testing.T) {
+	nes, _ := New(batteryROM(), nil, nil, nil)
+	nes.Start()
+	nes.cpuMemory.Write(0x6000, 0x5A)
+	nes.cpuMemory.Write(0x7FFF, 0xA5)
+
+	var sav bytes.Buffer
+	if err := nes.SaveSRAM(&sav); err != nil {
+		t.Fatal(err)
+	}
+
+	other, _ := New(batteryROM(), nil, nil, nil)
+	if err := other.LoadSRAM(bytes.NewReader(sav.Bytes())); err != nil {
+		t.Fatal(err)
+	}
+	other.Start()
+	if other.Peek(0x6000) != 0x5A || other.Peek(0x7FFF) != 0xA5 {
+		t.Errorf("SRAM not restored")
+	}
+
+	// Short or long .sav
+	for _, size := range []int{100, 8*1024 + 1} {
+		if err := other.LoadSRAM(bytes.NewReader(make([]byte, size))); err != cartridge.ErrInvalidSize {
+			t.Errorf("Size %v: got %v, want %v", size, err, cartridge.ErrInvalidSize)
+		}
+	}
+}
+
+func TestSRAMFlushOnStop(t *testing.T) {
+	file, err := ioutil.TempFile("", "nesrs-*.sav")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(file.Name())
+	defer file.Close()
+
+	nes, _ := New(batteryROM(), nil, nil, nil)
+	nes.SetSRAMWriter(file)
+	nes.Start()
+	nes.cpuMemory.Write(0x6000, 0x01)
+	if err := nes.Stop(); err != nil {
+		t.Fatal(err)
+	}
+
+	// Second Stop overwrites the first flush
+	nes.cpuMemory.Write(0x6000, 0x02)
+	if err := nes.Stop(); err != nil {
+		t.Fatal(err)
+	}
+
+	sav, err := ioutil.ReadFile(file.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(sav) != 8*1024 || sav[0] != 0x02 {
+		t.Errorf("Wrong flushed SRAM (%v bytes, first %02X)", len(sav), sav[0])
+	}
+}
+
+func TestSRAMNoBattery(t *testing.T) {
+	nes, _ := New(testROM(), nil, nil, nil)
+	if nes.IsBatteryBacked() {
+		t.Fatal("Unexpected battery")
+	}
+
+	if err := nes.SaveSRAM(new(bytes.Buffer)); err != cartridge.ErrNoBattery {
+		t.Errorf("SaveSRAM: got %v, want %v", err, cartridge.ErrNoBattery)
+	}
+	if err := nes.LoadSRAM(bytes.NewReader(make([]byte, 8*1024))); err != cartridge.ErrNoBattery {
+		t.Errorf("LoadSRAM: got %v, want %v", err, cartridge.ErrNoBattery)
+	}
+
+	// Nothing flushed on Stop
+	var sav bytes.Buffer
+	nes.SetSRAMWriter(&sav)
+	if err := nes.Stop(); err != nil || sav.Len() != 0 {
+		t.Errorf("Unexpected flush %v (%v bytes)", err, sav.Len())
+	}
+}
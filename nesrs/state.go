@@ -0,0 +1,152 @@
+package nesrs
+
+import (
+	"bytes"
+	"errors"
+	"io"
+
+	"github.com/alpetkov/nesrs_go/nesrs/controller"
+	"github.com/alpetkov/nesrs_go/nesrs/state"
+)
+
+// Save state header.
+const (
+	stateMagic   = "NESRS\x1A"
+	stateVersion = 4
+)
+
+// Save state errors.
+var (
+	ErrInvalidState = errors.New("nesrs: not a save state")
+	ErrStateVersion = errors.New("nesrs: unsupported save state version")
+	ErrStateROM     = errors.New("nesrs: save state is for a different ROM")
+	ErrStateDevices = errors.New("nesrs: save state has different controllers connected")
+)
+
+// Device which state is saved along the machine (e.g. latched buttons).
+type statefulDevice interface {
+	SaveState(writer *state.Writer)
+	LoadState(reader *state.Reader)
+}
+
+// SaveState writes a snapshot of the whole machine (CPU, RAM, PPU, APU, cartridge and controllers).
+// Host side receivers (video, audio, input provider) aren't saved.
+func (nes *NES) SaveState(writer io.Writer) error {
+	nes.lock.Lock()
+	defer nes.lock.Unlock()
+
+	return nes.saveState(writer)
+}
+
+func (nes *NES) saveState(writer io.Writer) error {
+	if _, err := io.WriteString(writer, stateMagic); err != nil {
+		return err
+	}
+
+	stateWriter := state.NewWriter(writer)
+	stateWriter.WriteInt(stateVersion)
+	stateWriter.WriteInt(int(int32(nes.romCRC)))
+
+	return nes.saveComponents(stateWriter)
+}
+
+// LoadState restores a snapshot written by SaveState for the same ROM. The machine is left
+// unchanged when the snapshot can't be loaded.
+func (nes *NES) LoadState(reader io.Reader) error {
+	nes.lock.Lock()
+	defer nes.lock.Unlock()
+
+	return nes.loadState(reader)
+}
+
+func (nes *NES) loadState(reader io.Reader) error {
+	magic := make([]byte, len(stateMagic))
+	if _, err := io.ReadFull(reader, magic); err != nil {
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			return ErrInvalidState
+		}
+		return err
+	}
+	if string(magic) != stateMagic {
+		return ErrInvalidState
+	}
+
+	stateReader := state.NewReader(reader)
+	version := stateReader.ReadInt()
+	romCRC := uint32(int32(stateReader.ReadInt()))
+	if err := stateReader.Err(); err != nil {
+		return err
+	}
+	if version != stateVersion {
+		return ErrStateVersion
+	}
+	if romCRC != nes.romCRC {
+		return ErrStateROM
+	}
+
+	// Keep current state in case the snapshot is corrupted
+	var backup bytes.Buffer
+	if err := nes.saveComponents(state.NewWriter(&backup)); err != nil {
+		return err
+	}
+
+	if err := nes.loadComponents(stateReader); err != nil {
+		nes.loadComponents(state.NewReader(&backup))
+		return err
+	}
+
+	// History before the loaded state doesn't lead to it
+	if nes.rewind != nil {
+		nes.rewind.clear()
+		nes.takeSnapshot()
+	}
+
+	return nil
+}
+
+func (nes *NES) saveComponents(writer *state.Writer) error {
+	nes.cpu.SaveState(writer)
+	nes.cpuMemory.SaveState(writer)
+	nes.ppu.SaveState(writer)
+	nes.apu.SaveState(writer)
+	nes.cartridge.SaveState(writer)
+	nes.input.saveState(writer)
+	writer.WriteBool(nes.isResetDue)
+	saveDeviceState(writer, nes.cpuMemory.Controller1())
+	saveDeviceState(writer, nes.cpuMemory.Controller2())
+
+	return writer.Err()
+}
+
+func (nes *NES) loadComponents(reader *state.Reader) error {
+	nes.cpu.LoadState(reader)
+	nes.cpuMemory.LoadState(reader)
+	nes.ppu.LoadState(reader)
+	nes.apu.LoadState(reader)
+	nes.cartridge.LoadState(reader)
+	nes.input.loadState(reader)
+	nes.isResetDue = reader.ReadBool()
+	loadDeviceState(reader, nes.cpuMemory.Controller1())
+	loadDeviceState(reader, nes.cpuMemory.Controller2())
+
+	return reader.Err()
+}
+
+func saveDeviceState(writer *state.Writer, device controller.Device) {
+	stateful, ok := device.(statefulDevice)
+	writer.WriteBool(ok)
+	if ok {
+		stateful.SaveState(writer)
+	}
+}
+
+func loadDeviceState(reader *state.Reader, device controller.Device) {
+	stateful, ok := device.(statefulDevice)
+	if reader.ReadBool() != ok {
+		reader.SetErr(ErrStateDevices)
+		return
+	}
+	if ok {
+		stateful.LoadState(reader)
+	}
+}
@@ -0,0 +1,153 @@
+// Package state serializes emulator components into a binary stream. Values are stored as
+// little-endian 32-bit integers. The first error is kept and makes further calls no-ops,
+// so components can save/load all their fields and check Err once.
+package state
+
+import (
+	"encoding/binary"
+	"errors"
+	"io"
+)
+
+// Errors.
+var (
+	// ErrSizeMismatch is returned when a stored array doesn't match the size of the destination.
+	ErrSizeMismatch = errors.New("state: size mismatch")
+	// ErrInvalidValue is returned when a loaded value is out of its valid range.
+	ErrInvalidValue = errors.New("state: invalid value")
+)
+
+// Writer - writes component state.
+type Writer struct {
+	writer io.Writer
+	buffer []byte
+	err    error
+}
+
+// NewWriter .
+func NewWriter(writer io.Writer) *Writer {
+	return &Writer{writer: writer}
+}
+
+// WriteInt .
+func (writer *Writer) WriteInt(value int) {
+	writer.WriteInts([]int{value})
+}
+
+// WriteBool .
+func (writer *Writer) WriteBool(value bool) {
+	if value {
+		writer.WriteInt(1)
+	} else {
+		writer.WriteInt(0)
+	}
+}
+
+// WriteInts without length.
+func (writer *Writer) WriteInts(values []int) {
+	if writer.err != nil {
+		return
+	}
+
+	size := 4 * len(values)
+	if cap(writer.buffer) < size {
+		writer.buffer = make([]byte, size)
+	}
+	buffer := writer.buffer[:size]
+	for i, value := range values {
+		binary.LittleEndian.PutUint32(buffer[4*i:], uint32(int32(value)))
+	}
+
+	_, writer.err = writer.writer.Write(buffer)
+}
+
+// WriteArray with length, to be read by ReadArray.
+func (writer *Writer) WriteArray(values []int) {
+	writer.WriteInt(len(values))
+	writer.WriteInts(values)
+}
+
+// Err - first error that occurred.
+func (writer *Writer) Err() error {
+	return writer.err
+}
+
+// Reader - reads component state.
+type Reader struct {
+	reader io.Reader
+	buffer []byte
+	err    error
+}
+
+// NewReader .
+func NewReader(reader io.Reader) *Reader {
+	return &Reader{reader: reader}
+}
+
+// ReadInt .
+func (reader *Reader) ReadInt() int {
+	values := []int{0}
+	reader.ReadInts(values)
+
+	return values[0]
+}
+
+// ReadBool .
+func (reader *Reader) ReadBool() bool {
+	return reader.ReadInt() != 0
+}
+
+// ReadIntRange reads a value that has to be in [min, limit), e.g. a table index. Out of range
+// values set ErrInvalidValue.
+func (reader *Reader) ReadIntRange(min int, limit int) int {
+	value := reader.ReadInt()
+	if value < min || value >= limit {
+		reader.SetErr(ErrInvalidValue)
+	}
+
+	return value
+}
+
+// ReadInts without length.
+func (reader *Reader) ReadInts(values []int) {
+	if reader.err != nil {
+		return
+	}
+
+	size := 4 * len(values)
+	if cap(reader.buffer) < size {
+		reader.buffer = make([]byte, size)
+	}
+	buffer := reader.buffer[:size]
+	if _, err := io.ReadFull(reader.reader, buffer); err != nil {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		reader.err = err
+		return
+	}
+
+	for i := range values {
+		values[i] = int(int32(binary.LittleEndian.Uint32(buffer[4*i:])))
+	}
+}
+
+// ReadArray written by WriteArray. Length must match the length of values.
+func (reader *Reader) ReadArray(values []int) {
+	if length := reader.ReadInt(); reader.err == nil && length != len(values) {
+		reader.err = ErrSizeMismatch
+	}
+	reader.ReadInts(values)
+}
+
+// Err - first error that occurred.
+func (reader *Reader) Err() error {
+	return reader.err
+}
+
+// SetErr - records an error found while validating loaded values (first error wins).
+func (reader *Reader) SetErr(err error) {
+	if reader.err == nil {
+		reader.err = err
+	}
+}
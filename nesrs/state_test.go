@@ -0,0 +1,113 @@
+package nesrs
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/alpetkov/nesrs_go/nesrs/state"
+)
+
+// testROM - NROM program incrementing RAM in a loop while rendering is enabled.
+func testROM() []byte {
+	rom := []byte{'N', 'E', 'S', 0x1A, 1, 1, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0}
+	prg := make([]byte, 0x4000)
+	// $8000: LDA #$80, STA $2000, LDA #$1E, STA $2001, loop: INC $10, JMP loop
+	copy(prg, []byte{0xA9, 0x80, 0x8D, 0x00, 0x20, 0xA9, 0x1E, 0x8D, 0x01, 0x20, 0xE6, 0x10, 0x4C, 0x0A, 0x80})
+	// NMI: INC $11, RTI
+	copy(prg[0x40:], []byte{0xE6, 0x11, 0x40})
+	prg[0x3FFA], prg[0x3FFB] = 0x40, 0x80
+	prg[0x3FFC], prg[0x3FFD] = 0x00, 0x80
+
+	rom = append(rom, prg...)
+	return append(rom, make([]byte, 0x2000)...)
+}
+
+func executeOps(nes *NES, count int) {
+	for i := 0; i < count; i++ {
+		cpuCycles := nes.cpu.ExecuteOp()
+		nes.ppu.ExecuteCycles(cpuCycles * 3)
+		nes.apu.ExecuteCycles(cpuCycles)
+	}
+}
+
+func TestSaveLoadState(t *testing.T) {
+	nes, err := New(testROM(), nil, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	nes.Start()
+	executeOps(nes, 10000)
+
+	var snapshot bytes.Buffer
+	if err := nes.SaveState(&snapshot); err != nil {
+		t.Fatal(err)
+	}
+
+	executeOps(nes, 50000)
+	var expected bytes.Buffer
+	nes.SaveState(&expected)
+
+	if err := nes.LoadState(bytes.NewReader(snapshot.Bytes())); err != nil {
+		t.Fatal(err)
+	}
+	executeOps(nes, 50000)
+	var actual bytes.Buffer
+	nes.SaveState(&actual)
+
+	if !bytes.Equal(expected.Bytes(), actual.Bytes()) {
+		t.Errorf("Different state after replaying from the snapshot")
+	}
+}
+
+func TestLoadStateErrors(t *testing.T) {
+	nes, _ := New(testROM(), nil, nil, nil)
+	nes.Start()
+	executeOps(nes, 1000)
+
+	var snapshot bytes.Buffer
+	nes.SaveState(&snapshot)
+	data := snapshot.Bytes()
+
+	otherROM := testROM()
+	otherROM[16] = 0xEA
+	other, _ := New(otherROM, nil, nil, nil)
+
+	tests := []struct {
+		nes  *NES
+		data []byte
+		want error
+	}{
+		{nes, []byte("garbage"), ErrInvalidState},
+		{nes, append([]byte(stateMagic), 99, 0, 0, 0, 0, 0, 0, 0), ErrStateVersion},
+		{other, data, ErrStateROM},
+	}
+	for i, test := range tests {
+		if err := test.nes.LoadState(bytes.NewReader(test.data)); err != test.want {
+			t.Errorf("Test %v: got %v, want %v", i, err, test.want)
+		}
+	}
+
+	// Truncated snapshot leaves the machine unchanged
+	var before, after bytes.Buffer
+	nes.SaveState(&before)
+	if err := nes.LoadState(bytes.NewReader(data[:len(data)/2])); err == nil {
+		t.Errorf("Truncated snapshot loaded")
+	}
+	nes.SaveState(&after)
+	if !bytes.Equal(before.Bytes(), after.Bytes()) {
+		t.Errorf("Machine changed by failed load")
+	}
+
+	// Out of range stack pointer (header, then CPU A, X, Y, S) is rejected
+	corrupted := append([]byte(nil), data...)
+	copy(corrupted[len(stateMagic)+8+3*4:], []byte{0x00, 0x01, 0x00, 0x00})
+	if err := nes.LoadState(bytes.NewReader(corrupted)); err != state.ErrInvalidValue {
+		t.Errorf("Corrupted snapshot: got %v, want %v", err, state.ErrInvalidValue)
+	}
+	after.Reset()
+	nes.SaveState(&after)
+	if !bytes.Equal(before.Bytes(), after.Bytes()) {
+		t.Errorf("Machine changed by corrupted load")
+	}
+	executeOps(nes, 1000)
+}
@@ -0,0 +1,228 @@
+package terminal
+
+import (
+	"io"
+	"sync"
+	"time"
+
+	"github.com/alpetkov/nesrs_go/nesrs/controller"
+)
+
+// Key names of special keys in KeyMap.
+const (
+	KeyUp    = "up"
+	KeyDown  = "down"
+	KeyLeft  = "left"
+	KeyRight = "right"
+	KeyEnter = "enter"
+	KeySpace = "space"
+	KeyTab   = "tab"
+)
+
+// DefaultHold - how long a key press holds the button. Terminals report key presses (and
+// auto-repeats) only, not releases.
+const DefaultHold = 250 * time.Millisecond
+
+// Ctrl-C in raw mode.
+const keyInterrupt = 0x03
+
+// KeyMap - keys (single characters or Key* names) to player 1 buttons.
+type KeyMap map[string]int
+
+// DefaultKeyMap - arrows or WASD, X/K for A, Z/J for B, Enter for Start, Space or Tab for Select.
+var DefaultKeyMap = KeyMap{
+	KeyUp: controller.ButtonUp, KeyDown: controller.ButtonDown,
+	KeyLeft: controller.ButtonLeft, KeyRight: controller.ButtonRight,
+	"w": controller.ButtonUp, "s": controller.ButtonDown,
+	"a": controller.ButtonLeft, "d": controller.ButtonRight,
+	"x": controller.ButtonA, "k": controller.ButtonA,
+	"z": controller.ButtonB, "j": controller.ButtonB,
+	KeyEnter: controller.ButtonStart,
+	KeySpace: controller.ButtonSelect, KeyTab: controller.ButtonSelect,
+}
+
+// Keyboard - controller.InputProvider for player 1 fed by raw terminal input. 'q' or Ctrl-C quits.
+type Keyboard struct {
+	lock    sync.Mutex
+	keyMap  KeyMap
+	hold    time.Duration
+	pressed map[int]time.Time // Button -> last press
+	pending []byte            // Incomplete escape sequence
+	quit    chan struct{}
+	isQuit  bool
+	now     func() time.Time
+}
+
+// NewKeyboard with the key map (DefaultKeyMap if nil). Reading from the reader (e.g. os.Stdin in
+// raw mode, see MakeRaw) starts in the background.
+func NewKeyboard(reader io.Reader, keyMap KeyMap) *Keyboard {
+	keyboard := newKeyboard(keyMap)
+	if reader != nil {
+		go keyboard.read(reader)
+	}
+
+	return keyboard
+}
+
+func newKeyboard(keyMap KeyMap) *Keyboard {
+	if keyMap == nil {
+		keyMap = DefaultKeyMap
+	}
+
+	keyboard := Keyboard{
+		keyMap:  keyMap,
+		hold:    DefaultHold,
+		pressed: make(map[int]time.Time),
+		quit:    make(chan struct{}),
+		now:     time.Now}
+
+	return &keyboard
+}
+
+// SetHold - how long a key press holds the button.
+func (keyboard *Keyboard) SetHold(hold time.Duration) {
+	keyboard.lock.Lock()
+	defer keyboard.lock.Unlock()
+
+	keyboard.hold = hold
+}
+
+// Quit is closed when the user asks to quit (or input ends).
+func (keyboard *Keyboard) Quit() <-chan struct{} {
+	return keyboard.quit
+}
+
+// ReadInput .
+func (keyboard *Keyboard) ReadInput(player int) int {
+	if player != 0 {
+		return 0
+	}
+
+	keyboard.lock.Lock()
+	defer keyboard.lock.Unlock()
+
+	buttons := 0
+	now := keyboard.now()
+	for button, pressed := range keyboard.pressed {
+		if now.Sub(pressed) < keyboard.hold {
+			buttons |= button
+		}
+	}
+
+	return buttons
+}
+
+func (keyboard *Keyboard) read(reader io.Reader) {
+	buffer := make([]byte, 64)
+	for {
+		n, err := reader.Read(buffer)
+		keyboard.feed(buffer[:n])
+		if err != nil {
+			keyboard.lock.Lock()
+			keyboard.setQuit()
+			keyboard.lock.Unlock()
+			return
+		}
+	}
+}
+
+// feed raw terminal input.
+func (keyboard *Keyboard) feed(data []byte) {
+	keyboard.lock.Lock()
+	defer keyboard.lock.Unlock()
+
+	data = append(keyboard.pending, data...)
+	keyboard.pending = nil
+
+	for len(data) > 0 {
+		key, length := parseKey(data)
+		if length == 0 {
+			// Wait for the rest of the escape sequence
+			keyboard.pending = append([]byte(nil), data...)
+			return
+		}
+		data = data[length:]
+
+		switch key {
+		case "q", string(rune(keyInterrupt)):
+			keyboard.setQuit()
+		default:
+			if button, ok := keyboard.keyMap[key]; ok {
+				keyboard.pressed[button] = keyboard.now()
+			}
+		}
+	}
+}
+
+func (keyboard *Keyboard) setQuit() {
+	if !keyboard.isQuit {
+		keyboard.isQuit = true
+		close(keyboard.quit)
+	}
+}
+
+// parseKey at the beginning of data. Returns key name and its length in bytes, 0 when an escape
+// sequence is incomplete.
+func parseKey(data []byte) (string, int) {
+	switch data[0] {
+	case '\r', '\n':
+		return KeyEnter, 1
+	case ' ':
+		return KeySpace, 1
+	case '\t':
+		return KeyTab, 1
+	case 0x1B:
+		return parseEscapeSequence(data)
+	}
+
+	if 'A' <= data[0] && data[0] <= 'Z' {
+		return string(rune(data[0] - 'A' + 'a')), 1
+	}
+
+	return string(rune(data[0])), 1
+}
+
+// parseEscapeSequence - CSI (ESC [ parameters final byte, e.g. ESC [1;5D for Ctrl-Left) or SS3
+// (ESC O A, application cursor keys). Modified arrows are reported as arrows, other keys are
+// skipped as a whole.
+func parseEscapeSequence(data []byte) (string, int) {
+	if len(data) < 2 {
+		return "", 0
+	}
+
+	length := 0
+	switch data[1] {
+	case '[':
+		// Parameter and intermediate bytes are 0x20-0x3F, final byte 0x40-0x7E
+		for length = 2; length < len(data) && 0x20 <= data[length] && data[length] <= 0x3F; length++ {
+		}
+		if length == len(data) {
+			return "", 0
+		}
+		if data[length] < 0x40 || data[length] > 0x7E {
+			// Broken sequence
+			return "", length
+		}
+		length++
+	case 'O':
+		if len(data) < 3 {
+			return "", 0
+		}
+		length = 3
+	default:
+		return "", 1
+	}
+
+	switch data[length-1] {
+	case 'A':
+		return KeyUp, length
+	case 'B':
+		return KeyDown, length
+	case 'C':
+		return KeyRight, length
+	case 'D':
+		return KeyLeft, length
+	}
+
+	return "", length
+}
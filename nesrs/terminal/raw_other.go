@@ -0,0 +1,13 @@
+//go:build !linux
+// +build !linux
+
+package terminal
+
+import (
+	"errors"
+)
+
+// MakeRaw is supported on Linux only.
+func MakeRaw(fd int) (func() error, error) {
+	return nil, errors.New("terminal: raw mode is not supported on this system")
+}
@@ -0,0 +1,103 @@
+package terminal
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/alpetkov/nesrs_go/nesrs/controller"
+	"github.com/alpetkov/nesrs_go/nesrs/ppu"
+)
+
+func TestVideoReceiver(t *testing.T) {
+	frame := make([]int, ppu.NESWidth*ppu.NESHeight)
+	for x := 0; x < ppu.NESWidth; x++ {
+		frame[x] = 0xFF0000                // Red upper row
+		frame[ppu.NESWidth+x] = 0x0000FF   // Blue lower row
+		frame[2*ppu.NESWidth+x] = 0x00FF00 // Green below
+	}
+
+	var out bytes.Buffer
+	videoReceiver := NewVideoReceiver(&out, 1)
+	videoReceiver.ReceiveFrame(frame)
+
+	lines := strings.Split(out.String(), "\r\n")
+	if len(lines) != ppu.NESHeight/2+1 {
+		t.Fatalf("Expected %d lines, got %d", ppu.NESHeight/2+1, len(lines))
+	}
+	if !strings.HasPrefix(lines[0], escHideCursor+escClear+escCursorHome+"\x1b[38;2;255;0;0m\x1b[48;2;0;0;255m"+upperHalf+upperHalf) {
+		t.Errorf("Unexpected first line %q", lines[0][:64])
+	}
+	if strings.Count(lines[0], upperHalf) != ppu.NESWidth {
+		t.Errorf("Expected %d characters", ppu.NESWidth)
+	}
+
+	// Downscaled 2x: red & blue averaged, green & black averaged
+	out.Reset()
+	videoReceiver = NewVideoReceiver(&out, 2)
+	videoReceiver.ReceiveFrame(frame)
+
+	lines = strings.Split(out.String(), "\r\n")
+	if len(lines) != ppu.NESHeight/4+1 {
+		t.Fatalf("Expected %d lines, got %d", ppu.NESHeight/4+1, len(lines))
+	}
+	if !strings.Contains(lines[0], "\x1b[38;2;127;0;127m\x1b[48;2;0;127;0m"+upperHalf) {
+		t.Errorf("Unexpected downscaled line %q", lines[0][:64])
+	}
+	if strings.Count(lines[0], upperHalf) != ppu.NESWidth/2 {
+		t.Errorf("Expected %d characters", ppu.NESWidth/2)
+	}
+}
+
+func TestKeyboard(t *testing.T) {
+	now := time.Unix(0, 0)
+	keyboard := newKeyboard(nil)
+	keyboard.now = func() time.Time { return now }
+
+	keyboard.feed([]byte("x\x1b[A"))
+	if buttons := keyboard.ReadInput(0); buttons != controller.ButtonA|controller.ButtonUp {
+		t.Errorf("Expected A and Up, got %02X", buttons)
+	}
+	if buttons := keyboard.ReadInput(1); buttons != 0 {
+		t.Errorf("Expected no input for player 2, got %02X", buttons)
+	}
+
+	// Split escape sequence
+	keyboard.feed([]byte("\x1b"))
+	keyboard.feed([]byte("OD\r"))
+	if buttons := keyboard.ReadInput(0); buttons != controller.ButtonA|controller.ButtonUp|controller.ButtonLeft|controller.ButtonStart {
+		t.Errorf("Expected A, Up, Left and Start, got %02X", buttons)
+	}
+
+	// Released after hold
+	now = now.Add(DefaultHold)
+	keyboard.feed([]byte("Z"))
+	if buttons := keyboard.ReadInput(0); buttons != controller.ButtonB {
+		t.Errorf("Expected B, got %02X", buttons)
+	}
+
+	// Modified arrows and other CSI keys (Page Up) don't leak their bytes (e.g. 'd' of Ctrl-Left)
+	now = now.Add(DefaultHold)
+	keyboard.feed([]byte("\x1b[5~\x1b[1;5D"))
+	if buttons := keyboard.ReadInput(0); buttons != controller.ButtonLeft {
+		t.Errorf("Expected Left, got %02X", buttons)
+	}
+	keyboard.feed([]byte("\x1b[1;2"))
+	keyboard.feed([]byte("Cs"))
+	if buttons := keyboard.ReadInput(0); buttons != controller.ButtonLeft|controller.ButtonRight|controller.ButtonDown {
+		t.Errorf("Expected Left, Right and Down, got %02X", buttons)
+	}
+
+	select {
+	case <-keyboard.Quit():
+		t.Fatal("Unexpected quit")
+	default:
+	}
+	keyboard.feed([]byte{keyInterrupt})
+	select {
+	case <-keyboard.Quit():
+	default:
+		t.Error("Expected quit on Ctrl-C")
+	}
+}
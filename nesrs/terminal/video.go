@@ -0,0 +1,134 @@
+// Package terminal renders NES frames in a terminal with 24-bit ANSI colors and reads
+// controller input from raw terminal keyboard input.
+package terminal
+
+import (
+	"bufio"
+	"io"
+	"strconv"
+
+	"github.com/alpetkov/nesrs_go/nesrs/ppu"
+)
+
+// Escape sequences.
+const (
+	escCursorHome = "\x1b[H"
+	escClear      = "\x1b[2J"
+	escHideCursor = "\x1b[?25l"
+	escShowCursor = "\x1b[?25h"
+	escReset      = "\x1b[0m"
+	upperHalf     = "▀" // Upper half block: foreground is the upper pixel, background the lower
+)
+
+// VideoReceiver draws frames with half-block characters, so every character cell shows two
+// pixels (upper & lower). Frames can be downscaled by an integer factor: 1 needs 256x120
+// characters, 2 needs 128x60, 4 needs 64x30.
+type VideoReceiver struct {
+	out     *bufio.Writer
+	scale   int
+	pixels  []int // Downscaled frame
+	width   int
+	height  int
+	started bool
+}
+
+// NewVideoReceiver drawing on out (e.g. os.Stdout) downscaled by scale (1 - 8).
+func NewVideoReceiver(out io.Writer, scale int) *VideoReceiver {
+	if scale < 1 {
+		scale = 1
+	} else if scale > 8 {
+		scale = 8
+	}
+
+	width := ppu.NESWidth / scale
+	height := ppu.NESHeight / scale
+	height += height & 0x01 // Even number of rows
+
+	videoReceiver := VideoReceiver{
+		out:    bufio.NewWriterSize(out, 64*1024),
+		scale:  scale,
+		pixels: make([]int, width*height),
+		width:  width,
+		height: height}
+
+	return &videoReceiver
+}
+
+// ReceiveFrame .
+func (videoReceiver *VideoReceiver) ReceiveFrame(frame []int) {
+	videoReceiver.downscale(frame)
+
+	out := videoReceiver.out
+	if !videoReceiver.started {
+		videoReceiver.started = true
+		out.WriteString(escHideCursor + escClear)
+	}
+	out.WriteString(escCursorHome)
+
+	lastUpper, lastLower := -1, -1
+	for y := 0; y < videoReceiver.height; y += 2 {
+		for x := 0; x < videoReceiver.width; x++ {
+			upper := videoReceiver.pixels[y*videoReceiver.width+x]
+			lower := videoReceiver.pixels[(y+1)*videoReceiver.width+x]
+			if upper != lastUpper {
+				writeColor(out, "38", upper)
+				lastUpper = upper
+			}
+			if lower != lastLower {
+				writeColor(out, "48", lower)
+				lastLower = lower
+			}
+			out.WriteString(upperHalf)
+		}
+		out.WriteString(escReset + "\r\n")
+		lastUpper, lastLower = -1, -1
+	}
+
+	out.Flush()
+}
+
+// Close restores terminal colors and cursor.
+func (videoReceiver *VideoReceiver) Close() error {
+	videoReceiver.out.WriteString(escReset + escShowCursor + "\r\n")
+	return videoReceiver.out.Flush()
+}
+
+// downscale frame by averaging scale x scale blocks.
+func (videoReceiver *VideoReceiver) downscale(frame []int) {
+	scale := videoReceiver.scale
+	for y := 0; y < videoReceiver.height; y++ {
+		for x := 0; x < videoReceiver.width; x++ {
+			r, g, b, count := 0, 0, 0, 0
+			for dy := 0; dy < scale; dy++ {
+				frameY := y*scale + dy
+				if frameY >= ppu.NESHeight {
+					break
+				}
+				for dx := 0; dx < scale; dx++ {
+					rgb := frame[frameY*ppu.NESWidth+x*scale+dx]
+					r += (rgb >> 16) & 0xFF
+					g += (rgb >> 8) & 0xFF
+					b += rgb & 0xFF
+					count++
+				}
+			}
+
+			rgb := 0
+			if count > 0 {
+				rgb = (r/count)<<16 | (g/count)<<8 | b/count
+			}
+			videoReceiver.pixels[y*videoReceiver.width+x] = rgb
+		}
+	}
+}
+
+// writeColor - 24-bit foreground (38) or background (48) color.
+func writeColor(out *bufio.Writer, target string, rgb int) {
+	out.WriteString("\x1b[" + target + ";2;")
+	out.WriteString(strconv.Itoa((rgb >> 16) & 0xFF))
+	out.WriteByte(';')
+	out.WriteString(strconv.Itoa((rgb >> 8) & 0xFF))
+	out.WriteByte(';')
+	out.WriteString(strconv.Itoa(rgb & 0xFF))
+	out.WriteByte('m')
+}
@@ -7,7 +7,6 @@ import (
 	"log"
 	"os"
 	"testing"
-	"time"
 
 	"github.com/alpetkov/nesrs_go/nesrs"
 	"github.com/alpetkov/nesrs_go/nesrs/ppu"
@@ -18,17 +17,13 @@ func TestRom(romPath string, screenshotPath string, numberOfSeconds int, t *test
 
 	ppmVideoReceiver := new(ppu.PPMVideoReceiver)
 
-	nes := nesrs.New(romBytes, ppmVideoReceiver)
+	nes, err := nesrs.New(romBytes, ppmVideoReceiver, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
 	nes.Start()
-
-	start := time.Now().Unix()
-	end := start
-
-	go nes.Run()
-
-	for end-start < int64(numberOfSeconds) {
-		time.Sleep(1 * time.Second)
-		end = time.Now().Unix()
+	for nes.Frame() < numberOfSeconds*60 {
+		nes.RunFrame()
 	}
 	nes.Stop()
 